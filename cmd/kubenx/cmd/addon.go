@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Create Command for managing EKS add-ons
+func NewCmdAddon() *cobra.Command {
+	return NewCmd("addon").
+		WithDescription("EKS add-on related command").
+		SetAliases([]string{"addons"}).
+		AddCommand(NewCmdAddonList()).
+		AddCommand(NewCmdAddonDescribe()).
+		AddCommand(NewCmdAddonUpdate()).
+		SetFlags().
+		RunWithArgsAndCmd(execAddon)
+}
+
+func execAddon(_ context.Context, _ io.Writer, cmd *cobra.Command, args []string) error {
+	cmd.Help()
+	return nil
+}
+
+// Create Command for listing add-ons
+func NewCmdAddonList() *cobra.Command {
+	return NewCmd("list").
+		WithDescription("List add-ons with latest version compatible with the cluster").
+		SetAliases([]string{"ls"}).
+		RunWithNoArgs(execAddonList)
+}
+
+// Function for list add-on command
+func execAddonList(ctx context.Context, out io.Writer) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		cluster := executor.EKSCluster.Name
+
+		kubernetesVersion, err := getClusterVersion(executor)
+		if err != nil {
+			return err
+		}
+
+		addons, err := runner.GetAllAddonStatus(executor.EKS, cluster, kubernetesVersion)
+		if err != nil {
+			return err
+		}
+
+		if !runner.RenderAddonListInfo(addons) {
+			color.Red.Fprintln(out, fmt.Sprintf("No add-on is installed in %s", cluster))
+		}
+
+		return nil
+	})
+}
+
+// Create Command for describing add-on
+func NewCmdAddonDescribe() *cobra.Command {
+	return NewCmd("describe").
+		WithDescription("Describe add-on with configuration values and health issues").
+		RunWithArgs(execAddonDescribe)
+}
+
+// Function for describe add-on command
+func execAddonDescribe(ctx context.Context, out io.Writer, args []string) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		cluster := executor.EKSCluster.Name
+
+		kubernetesVersion, err := getClusterVersion(executor)
+		if err != nil {
+			return err
+		}
+
+		target, err := runner.GetTargetAddon(executor.EKS, cluster, args)
+		if err != nil {
+			return err
+		}
+
+		addon, err := runner.GetAddonStatus(executor.EKS, cluster, target, kubernetesVersion)
+		if err != nil {
+			return err
+		}
+
+		runner.RenderAddonDetail(addon)
+
+		if values := addon.Addon.ConfigurationValues; values != nil && len(*values) > 0 {
+			fmt.Fprintln(out)
+			color.Yellow.Fprintln(out, "========Configuration Values=======")
+			fmt.Fprintln(out, *values)
+		}
+
+		return nil
+	})
+}
+
+// Create Command for updating add-on
+func NewCmdAddonUpdate() *cobra.Command {
+	return NewCmd("update").
+		WithDescription("Update add-on to the latest compatible version or with configuration values").
+		MarkAsMutating().
+		RunWithArgs(execAddonUpdate)
+}
+
+// Function for update add-on command
+func execAddonUpdate(ctx context.Context, out io.Writer, args []string) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		cluster := executor.EKSCluster.Name
+
+		resolveConflicts := strings.ToUpper(viper.GetString("resolve-conflicts"))
+		if len(resolveConflicts) > 0 && !utils.IsStringInArray(resolveConflicts, runner.ADDON_RESOLVE_CONFLICTS) {
+			return fmt.Errorf("--resolve-conflicts should be one of %s", strings.Join(runner.ADDON_RESOLVE_CONFLICTS, ", "))
+		}
+
+		configurationValues, err := getConfigurationValues(viper.GetString("configuration-values"))
+		if err != nil {
+			return err
+		}
+
+		kubernetesVersion, err := getClusterVersion(executor)
+		if err != nil {
+			return err
+		}
+
+		target, err := runner.GetTargetAddon(executor.EKS, cluster, args)
+		if err != nil {
+			return err
+		}
+
+		addon, err := runner.GetAddonStatus(executor.EKS, cluster, target, kubernetesVersion)
+		if err != nil {
+			return err
+		}
+
+		current := *addon.Addon.AddonVersion
+		version := viper.GetString("addon-version")
+		if len(version) == 0 {
+			version = addon.LatestVersion
+		}
+
+		if len(version) == 0 {
+			return fmt.Errorf("no version of %s is compatible with kubernetes %s", target, kubernetesVersion)
+		}
+
+		if version == current && len(configurationValues) == 0 {
+			color.Blue.Fprintln(out, fmt.Sprintf("%s is already %s", target, current))
+			return nil
+		}
+
+		if version != current {
+			color.Yellow.Fprintln(out, fmt.Sprintf("Update %s from %s to %s", target, current, version))
+		}
+		if len(configurationValues) > 0 {
+			color.Yellow.Fprintln(out, "Configuration values will be replaced")
+		}
+
+		confirmed, err := confirmUpdate(fmt.Sprintf("Update %s in %s?", target, cluster))
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			color.Red.Fprintln(out, "Update has been canceled")
+			return nil
+		}
+
+		// Version is not sent if only configuration values are changed
+		updateVersion := version
+		if version == current {
+			updateVersion = utils.NO_STRING
+		}
+
+		update, err := aws.UpdateAddon(executor.EKS, cluster, target, updateVersion, resolveConflicts, configurationValues)
+		if err != nil {
+			return err
+		}
+
+		describe := func(updateID string) (*eks.Update, error) {
+			return aws.GetAddonUpdateInfo(executor.EKS, cluster, target, updateID)
+		}
+
+		if err := waitForEKSUpdate(ctx, out, update, describe); err != nil {
+			return err
+		}
+
+		color.Green.Fprintln(out, fmt.Sprintf("%s has been updated to %s", target, version))
+		return nil
+	})
+}
+
+// Get kubernetes version of the cluster
+func getClusterVersion(executor Executor) (string, error) {
+	clusterInfo, err := aws.GetClusterInfo(executor.EKS, executor.EKSCluster.Name)
+	if err != nil {
+		return utils.NO_STRING, err
+	}
+
+	return *clusterInfo.Cluster.Version, nil
+}
+
+// Get configuration values from flag, which could be @<file>
+func getConfigurationValues(value string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+
+	content, err := ioutil.ReadFile(strings.TrimPrefix(value, "@"))
+	if err != nil {
+		return utils.NO_STRING, err
+	}
+
+	return string(content), nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	// Annotation of commands changing resources of the cluster
+	ANNOTATION_MUTATING = "kubenx/mutating"
+
+	// Output of these commands is read by other programs, so banner should not be printed
+	BANNER_SKIPPED_COMMANDS = []string{"token", "completion", "help", "version"}
+)
+
+// Command checked after its target EKS cluster is found, because --cluster flag could target another context
+type deferredContextCheck struct {
+	out      io.Writer
+	executed *cobra.Command
+}
+
+var pendingContextCheck *deferredContextCheck
+
+// Print banner of current context, and ask confirmation before mutating protected context
+func checkCurrentContext(out io.Writer, executed *cobra.Command) error {
+	if utils.IsStringInArray(executed.Name(), BANNER_SKIPPED_COMMANDS) {
+		return nil
+	}
+
+	// Shell whose session kubeconfig is removed should not fall back to current context of other files.
+	// New session can be started with --session
+	if !viper.GetBool("session") {
+		if err := runner.CheckSessionKubeconfig(); err != nil {
+			return err
+		}
+	}
+
+	// Context of the cluster given by --cluster flag is checked in runExecutorWithAWS
+	if flag := executed.Flags().Lookup("cluster"); flag != nil && flag.Changed {
+		pendingContextCheck = &deferredContextCheck{out: out, executed: executed}
+		return nil
+	}
+
+	// Commands not using kubeconfig should work without it
+	currentConfig, err := runner.GetCurrentConfig()
+	if err != nil || len(currentConfig.CurrentContext) == 0 {
+		return nil
+	}
+
+	return checkContext(out, executed, currentConfig.CurrentContext)
+}
+
+// Print banner of the context of target EKS cluster, and ask confirmation before mutating protected context
+func checkTargetClusterContext(cluster runner.EKSClusterLocation) error {
+	check := pendingContextCheck
+	if check == nil {
+		return nil
+	}
+	pendingContextCheck = nil
+
+	if len(cluster.Context) > 0 {
+		return checkContext(check.out, check.executed, cluster.Context)
+	}
+
+	// Protection is unknown without context, so the cluster is regarded as protected
+	color.Yellow.Fprintln(check.out, fmt.Sprintf("[ Cluster: %s | Region: %s | No context ]", cluster.Name, cluster.Region))
+	if isMutatingCommand(check.executed) {
+		color.Red.Fprintln(check.out, fmt.Sprintf("Kubeconfig has no context of cluster %s, so it cannot be checked whether it is protected.", cluster.Name))
+		return confirmTypedName("Type the cluster name to continue:", cluster.Name)
+	}
+
+	return nil
+}
+
+// Print banner of the context, and ask confirmation before mutating the context if it is protected
+func checkContext(out io.Writer, executed *cobra.Command, contextName string) error {
+	metadata := aws.FindContextMetadata(contextName)
+	printContextBanner(out, contextName, metadata)
+
+	if metadata.Protected && isMutatingCommand(executed) {
+		return confirmProtectedContext(out, contextName)
+	}
+
+	return nil
+}
+
+// Print current context with the color and environment from metadata
+func printContextBanner(out io.Writer, contextName string, metadata aws.ContextMetadata) {
+	banner := []string{fmt.Sprintf("Context: %s", contextName)}
+	if len(metadata.Env) > 0 {
+		banner = append(banner, fmt.Sprintf("Env: %s", metadata.Env))
+	}
+	if metadata.Protected {
+		banner = append(banner, "Protected")
+	}
+
+	color.ByName(metadata.Color).Fprintln(out, fmt.Sprintf("[ %s ]", strings.Join(banner, " | ")))
+}
+
+// Check whether the command or its parents are marked as mutating
+func isMutatingCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[ANNOTATION_MUTATING]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ask user to type the context name to run mutating command on protected context
+func confirmProtectedContext(out io.Writer, contextName string) error {
+	color.Red.Fprintln(out, fmt.Sprintf("Context %s is protected.", contextName))
+
+	return confirmTypedName("Type the context name to continue:", contextName)
+}
+
+// Ask user to type the name to continue
+func confirmTypedName(message, name string) error {
+	var typed string
+	prompt := &survey.Input{Message: message}
+	if err := survey.AskOne(prompt, &typed, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(typed) != name {
+		return fmt.Errorf("name does not match, command is canceled")
+	}
+
+	return nil
+}
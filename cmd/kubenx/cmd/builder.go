@@ -16,6 +16,8 @@ type Builder interface {
 	AddInspectGroups() Builder
 	AddConfigGroups() Builder
 	SetFlags() Builder
+	SetOwnFlags() Builder
+	MarkAsMutating() Builder
 	RunWithNoArgs(action func(context.Context, io.Writer) error) *cobra.Command
 	RunWithArgs(action func(context.Context, io.Writer, []string) error) *cobra.Command
 	RunWithArgsAndCmd(action func(context.Context, io.Writer, *cobra.Command, []string) error) *cobra.Command
@@ -35,25 +37,25 @@ func NewCmd(use string) Builder {
 }
 
 // Write short description
-func (b builder) WithDescription(description string) Builder {
+func (b *builder) WithDescription(description string) Builder {
 	b.cmd.Short = description
 	return b
 }
 
 // Write long description
-func (b builder) WithLongDescription(description string) Builder {
+func (b *builder) WithLongDescription(description string) Builder {
 	b.cmd.Long = description
 	return b
 }
 
 // Set command alias
-func (b builder) SetAliases(alias []string) Builder {
+func (b *builder) SetAliases(alias []string) Builder {
 	b.cmd.Aliases = alias
 	return b
 }
 
 //Run command without Argument
-func (b builder) RunWithNoArgs(function func(context.Context, io.Writer) error) *cobra.Command {
+func (b *builder) RunWithNoArgs(function func(context.Context, io.Writer) error) *cobra.Command {
 	b.cmd.Args = cobra.NoArgs
 	b.cmd.RunE = func(*cobra.Command, []string) error {
 		return returnErrorFromFunction(function(b.cmd.Context(), b.cmd.OutOrStderr()))
@@ -62,7 +64,7 @@ func (b builder) RunWithNoArgs(function func(context.Context, io.Writer) error)
 }
 
 // Run command with extra arguments
-func (b builder) RunWithArgs(function func(context.Context, io.Writer, []string) error) *cobra.Command {
+func (b *builder) RunWithArgs(function func(context.Context, io.Writer, []string) error) *cobra.Command {
 	b.cmd.RunE = func(_ *cobra.Command, args []string) error {
 		return returnErrorFromFunction(function(b.cmd.Context(), b.cmd.OutOrStderr(), args))
 	}
@@ -70,30 +72,47 @@ func (b builder) RunWithArgs(function func(context.Context, io.Writer, []string)
 }
 
 // Run command with extra arguments
-func (b builder) RunWithArgsAndCmd(function func(context.Context, io.Writer, *cobra.Command, []string) error) *cobra.Command {
+func (b *builder) RunWithArgsAndCmd(function func(context.Context, io.Writer, *cobra.Command, []string) error) *cobra.Command {
 	b.cmd.RunE = func(_ *cobra.Command, args []string) error {
 		return returnErrorFromFunction(function(b.cmd.Context(), b.cmd.OutOrStderr(), &b.cmd, args))
 	}
 	return &b.cmd
 }
 
-func (b builder) SetFlags() Builder {
+func (b *builder) SetFlags() Builder {
 	SetCommandFlags(&b.cmd)
 	return b
 }
 
+// Set flags defined on the command itself, not on its children
+func (b *builder) SetOwnFlags() Builder {
+	setFlagsOnCommand(&b.cmd)
+	return b
+}
+
+// Mark command as changing resources, which needs confirmation on protected context
+func (b *builder) MarkAsMutating() Builder {
+	if b.cmd.Annotations == nil {
+		b.cmd.Annotations = map[string]string{}
+	}
+	b.cmd.Annotations[ANNOTATION_MUTATING] = "true"
+	return b
+}
+
 // Set Child of command
-func (b builder) AddCommand(child *cobra.Command) Builder {
+func (b *builder) AddCommand(child *cobra.Command) Builder {
 	b.cmd.AddCommand(child)
 	return b
 }
 
 // Add groups of commands for get command
-func (b builder) AddGetGroups() Builder {
+func (b *builder) AddGetGroups() Builder {
 	b.cmd.AddCommand(NewCmdGetPod())
 	b.cmd.AddCommand(NewCmdGetService())
 	b.cmd.AddCommand(NewCmdGetDeployment())
 	b.cmd.AddCommand(NewCmdGetCluster())
+	b.cmd.AddCommand(NewCmdGetNodegroup())
+	b.cmd.AddCommand(NewCmdGetFargateProfile())
 	b.cmd.AddCommand(NewCmdGetIngress())
 	b.cmd.AddCommand(NewCmdGetNode())
 	b.cmd.AddCommand(NewCmdGetConfigMap())
@@ -108,22 +127,27 @@ func (b builder) AddGetGroups() Builder {
 }
 
 // Add groups of commands for search command
-func (b builder) AddSearchGroups() Builder {
+func (b *builder) AddSearchGroups() Builder {
 	b.cmd.AddCommand(NewCmdSearchLabel())
+	b.cmd.AddCommand(NewCmdSearchRefs())
+	b.cmd.AddCommand(NewCmdSearchImage())
 	return b
 }
 
 // Add groups of commands for search command
-func (b builder) AddInspectGroups() Builder {
+func (b *builder) AddInspectGroups() Builder {
 	b.cmd.AddCommand(NewCmdInspectNode())
+	b.cmd.AddCommand(NewCmdInspectNodegroup())
+	b.cmd.AddCommand(NewCmdInspectCerts())
 	return b
 }
 
 // Add groups of commands for config command
-func (b builder) AddConfigGroups() Builder {
+func (b *builder) AddConfigGroups() Builder {
 	b.cmd.AddCommand(NewCmdConfigDelete())
 	b.cmd.AddCommand(NewCmdConfigUpdate())
 	b.cmd.AddCommand(NewCmdConfigInit())
+	b.cmd.AddCommand(NewCmdConfigSync())
 	return b
 }
 
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"time"
+)
+
+//Create Command for inspect certificates
+func NewCmdInspectCerts() *cobra.Command {
+	return NewCmd("certs").
+		WithDescription("Find certificates expiring soon in the cluster").
+		WithLongDescription(`Scan certificates in the cluster and report anything expiring soon.
+
+Targets are tls secrets in all namespaces, caBundle of admission webhooks
+and cluster CA in kubeconfig. Use --exit-code to fail in CI if any certificate
+expires within --days.`).
+		SetAliases([]string{"cert", "certificates"}).
+		RunWithNoArgs(execInspectCerts)
+}
+
+// Function for inspect certificates command
+func execInspectCerts(ctx context.Context, out io.Writer) error {
+	return runExecutor(ctx, func(executor Executor) error {
+		days := viper.GetInt("days")
+		now := time.Now()
+		scanned := []runner.CertificateExpiry{}
+
+		// 1. Certificates in tls secrets
+		secrets, err := executor.Client.CoreV1().Secrets(utils.ALL_NAMESPACE).List(ctx, metav1.ListOptions{FieldSelector: fmt.Sprintf("type=%s", corev1.SecretTypeTLS)})
+		if err != nil {
+			return err
+		}
+
+		for _, secret := range secrets.Items {
+			for _, key := range []string{corev1.TLSCertKey, corev1.ServiceAccountRootCAKey} {
+				data := secret.Data[key]
+				if len(data) == 0 {
+					continue
+				}
+
+				expiries, err := runner.GetCertificateExpiries("Secret", secret.Namespace, secret.Name+"/"+key, data, now)
+				if err != nil {
+					color.Red.Fprintln(out, fmt.Sprintf("cannot parse %s/%s/%s: %s", secret.Namespace, secret.Name, key, err.Error()))
+					continue
+				}
+				scanned = append(scanned, expiries...)
+			}
+		}
+
+		// 2. CA bundles of admission webhooks
+		webhooks, err := runner.GetAllWebhookCABundles(ctx, executor.Client)
+		if err != nil {
+			return err
+		}
+
+		for _, webhook := range webhooks {
+			if len(webhook.CABundle) == 0 {
+				continue
+			}
+
+			expiries, err := runner.GetCertificateExpiries(webhook.Kind, utils.NO_STRING, webhook.Name, webhook.CABundle, now)
+			if err != nil {
+				color.Red.Fprintln(out, fmt.Sprintf("cannot parse caBundle of %s: %s", webhook.Name, err.Error()))
+				continue
+			}
+			scanned = append(scanned, expiries...)
+		}
+
+		// 3. Cluster CA in kubeconfig
+		caData := executor.Config.TLSClientConfig.CAData
+		if len(caData) == 0 && len(executor.Config.TLSClientConfig.CAFile) > 0 {
+			caData, err = ioutil.ReadFile(executor.Config.TLSClientConfig.CAFile)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(caData) > 0 {
+			expiries, err := runner.GetCertificateExpiries("Kubeconfig", utils.NO_STRING, executor.Config.Host, caData, now)
+			if err != nil {
+				color.Red.Fprintln(out, fmt.Sprintf("cannot parse cluster CA in kubeconfig: %s", err.Error()))
+			} else {
+				scanned = append(scanned, expiries...)
+			}
+		}
+
+		// Filter certificates expiring within the window
+		expiring := []runner.CertificateExpiry{}
+		for _, expiry := range scanned {
+			if expiry.DaysLeft <= days {
+				expiring = append(expiring, expiry)
+			}
+		}
+
+		sort.SliceStable(expiring, func(i, j int) bool {
+			return expiring[i].DaysLeft < expiring[j].DaysLeft
+		})
+
+		color.Blue.Fprintln(out, fmt.Sprintf("Scanned %d certificates, %d expire within %d days", len(scanned), len(expiring), days))
+		if !runner.RenderCertificateExpiryList(expiring) {
+			color.Green.Fprintln(out, fmt.Sprintf("No certificate expires within %d days", days))
+			return nil
+		}
+
+		if viper.GetBool("exit-code") {
+			return fmt.Errorf("%d certificates expire within %d days", len(expiring), days)
+		}
+
+		return nil
+	})
+}
@@ -7,8 +7,10 @@ import (
 	"github.com/GwonsooLee/kubenx/pkg/color"
 	"github.com/GwonsooLee/kubenx/pkg/runner"
 	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"io"
 	"os"
 	"strings"
@@ -23,6 +25,7 @@ func NewCmdCluster() *cobra.Command {
 	return NewCmd("cluster").
 		WithDescription("Cluster related command").
 		AddCommand(NewCmdInitCluster()).
+		AddCommand(NewCmdUpgradeCheckCluster()).
 		SetFlags().
 		RunWithNoArgs(execCluster)
 }
@@ -41,12 +44,13 @@ func execCluster(ctx context.Context, out io.Writer) error {
 // Function for getting services
 func execGetCluster(ctx context.Context, out io.Writer) error {
 	return runExecutorWithAWS(ctx, func(executor Executor) error {
-		// Check the cluster First
-		cluster, err := runner.GetCurrentCluster()
-		if err != nil {
-			return err
+		if viper.GetBool("all") {
+			return listAllClusters(out, executor)
 		}
 
+		// Check the cluster First
+		cluster := executor.EKSCluster.Name
+
 		// 1. Get Cluster Information
 		clusterInfo, err := aws.GetClusterInfo(executor.EKS, cluster)
 		if err != nil {
@@ -117,23 +121,47 @@ func execGetCluster(ctx context.Context, out io.Writer) error {
 	})
 }
 
+// List all clusters in the region of current cluster, or in the region of --region flag if it is set
+func listAllClusters(out io.Writer, executor Executor) error {
+	region := executor.EKSCluster.Region
+	if viper.IsSet("region") {
+		region = viper.GetString("region")
+	}
+	svc := aws.GetEksSessionInRegion(runner.GetRoleReference(executor.EKSCluster.Role), region)
+
+	names, err := aws.ListAllClusters(svc)
+	if err != nil {
+		return err
+	}
+
+	clusters := []*eks.Cluster{}
+	for _, name := range names {
+		clusterInfo, err := aws.GetClusterInfo(svc, name)
+		if err != nil {
+			return err
+		}
+		clusters = append(clusters, clusterInfo.Cluster)
+	}
+
+	if !runner.RenderClusterListInfo(clusters) {
+		color.Red.Fprintln(out, fmt.Sprintf("No cluster exists in %s", region))
+	}
+
+	return nil
+}
+
 //Init Cluster
 func NewCmdInitCluster() *cobra.Command {
 	return NewCmd("init").
 		WithDescription("Initiating the EKS cluster for further usage").
+		MarkAsMutating().
 		RunWithNoArgs(execInitCluster)
 }
 
 // Function for init cluster services
 func execInitCluster(ctx context.Context, out io.Writer) error {
 	return runExecutorWithAWS(ctx, func(executor Executor) error {
-
-		// Get Cluster Information First
-		// Check the cluster First
-		cluster, err := runner.GetCurrentCluster()
-		if err != nil {
-			return err
-		}
+		cluster := executor.EKSCluster.Name
 
 		// 1. Get Cluster Information
 		clusterInfo, err := aws.GetClusterInfo(executor.EKS, cluster)
@@ -141,126 +169,71 @@ func execInitCluster(ctx context.Context, out io.Writer) error {
 			return err
 		}
 
-		// 2. Get VPC ID and subnets in that VPC
-		vpcId := clusterInfo.Cluster.ResourcesVpcConfig.VpcId
-		subnetList, err := aws.GetSubnetListInVPC(executor.EC2, vpcId)
+		// 2. Find tags and OIDC provider to be changed
+		plan, err := runner.GetClusterInitPlan(executor.EC2, executor.IAM, clusterInfo.Cluster)
 		if err != nil {
 			return err
 		}
 
-		if len(subnetList.Subnets) == 0 {
-			return fmt.Errorf("No subnet exists, please checkout out VPC")
-		}
+		color.Yellow.Fprintln(out, fmt.Sprintf("Changes for initializing %s", cluster))
+		runner.RenderClusterInitPlan(plan)
 
-		// 3. Get VPC Information
-		vpcInfo, err := aws.GetVPCInfo(executor.EC2, vpcId)
-		if err != nil {
-			return err
+		if !plan.HasChanges() {
+			color.Blue.Fprintln(out, fmt.Sprintf("%s is already initialized", cluster))
+			return nil
 		}
 
-		// Tags
-		tags := vpcInfo.Vpcs[0].Tags
-
-		hasVPCTag := false
-		for _, tag := range tags {
-			if *tag.Key == "kubernetes.io/cluster/"+cluster && *tag.Value == "shared" {
-				hasVPCTag = true
-			}
+		if viper.GetBool("dry-run") {
+			color.Yellow.Fprintln(out, "Dry run: nothing has been changed")
+			return nil
 		}
 
-		// Print the description for initialization
-		color.Red.Fprintln(out, "******* Steps for initialization ********")
-		color.Yellow.Fprintln(out, "Step 1. Tag setup for VPC")
-		color.Yellow.Fprintln(out, "Step 2. Tag setup for public subnet")
-		color.Yellow.Fprintln(out, "Step 3. Tag setup for private subnet")
-		color.Yellow.Fprintln(out, "Step 4. Create Open ID Connector")
-		fmt.Println()
-
-		// Check the vpc tag is updated
-		if hasVPCTag {
-			color.Blue.Fprintln(out, "Step 1. VPC Tag is already updated")
-		} else {
-			color.Red.Fprintln(out, "Step 1. VPC Tag needs to be updated")
-			aws.UpdateVPCTagForCluster(executor.EC2, vpcId, cluster)
+		confirmed, err := confirmUpdate(fmt.Sprintf("Apply changes to AWS resources of %s?", cluster))
+		if err != nil {
+			return err
 		}
 
-		// 4. Set Subnet Information
-		var publicSubnetIds []*string
-		var privateSubnetIds []*string
-		for _, subnet := range subnetList.Subnets {
-			// Check public subnet
-			clusterNameSetup := false
-			ELBTypeSetup := false
-			isFilteredSubnet := false
-			if *subnet.MapPublicIpOnLaunch {
-				for _, tag := range subnet.Tags {
-					if *tag.Key == "kubernetes.io/cluster/"+cluster && *tag.Value == "shared" {
-						clusterNameSetup = true
-					}
-
-					if *tag.Key == "kubernetes.io/role/elb" && *tag.Value == "1" {
-						ELBTypeSetup = true
-					}
-
-					if *tag.Key == "Name" && strings.HasPrefix(*tag.Value, "db") {
-						isFilteredSubnet = true
-						break
-					}
-				}
-
-				if !(ELBTypeSetup && clusterNameSetup) && !isFilteredSubnet {
-					publicSubnetIds = append(publicSubnetIds, subnet.SubnetId)
-				}
-			} else {
-				for _, tag := range subnet.Tags {
-					if *tag.Key == "kubernetes.io/cluster/"+cluster && *tag.Value == "shared" {
-						clusterNameSetup = true
-					}
-
-					if *tag.Key == "kubernetes.io/role/runner-elb" && *tag.Value == "1" {
-						ELBTypeSetup = true
-					}
-
-					if *tag.Key == "Name" && strings.HasPrefix(*tag.Value, "db") {
-						isFilteredSubnet = true
-						break
-					}
-				}
+		if !confirmed {
+			color.Red.Fprintln(out, "Initialization has been canceled")
+			return nil
+		}
 
-				if !(ELBTypeSetup && clusterNameSetup) && !isFilteredSubnet {
-					privateSubnetIds = append(privateSubnetIds, subnet.SubnetId)
-				}
+		// 3. Apply changes
+		if len(plan.VPC) > 0 {
+			if err := aws.UpdateVPCTagForCluster(executor.EC2, clusterInfo.Cluster.ResourcesVpcConfig.VpcId, cluster); err != nil {
+				return fmt.Errorf("cannot update tags of VPC %s: %s", plan.VpcId, err.Error())
 			}
-
+			color.Green.Fprintln(out, "Step 1. VPC Tag is successfully updated")
 		}
 
-		// Add Tag if there is public subnet which doesn't have the necessary tags
-		if len(publicSubnetIds) > 0 {
-			color.Red.Fprintln(out, "Step 2. Tags for Public Subnet needs to be updated")
-			aws.UpdateSubnetsTagForCluster(executor.EC2, publicSubnetIds, cluster, "public")
-		} else {
-			utils.Blue("Step 2. Tags for Public Subnet is already updated")
+		if len(plan.PublicSubnets) > 0 {
+			if err := aws.UpdateSubnetsTagForCluster(executor.EC2, runner.GetResourceIds(plan.PublicSubnets), cluster, aws.SUBNET_TYPE_PUBLIC); err != nil {
+				return fmt.Errorf("cannot update tags of public subnets: %s", err.Error())
+			}
+			color.Green.Fprintln(out, "Step 2. Tags for Public Subnet are successfully updated")
 		}
 
-		// Add Tag if there is private subnet which doesn't have the necessary tags
-		if len(privateSubnetIds) > 0 {
-			color.Red.Fprintln(out, "Step 3. Tags for Private Subnet needs to be updated")
-			aws.UpdateSubnetsTagForCluster(executor.EC2, privateSubnetIds, cluster, "private")
-		} else {
-			color.Blue.Fprintln(out, "Step 3. Tags for Private Subnet is already updated")
+		if len(plan.PrivateSubnets) > 0 {
+			if err := aws.UpdateSubnetsTagForCluster(executor.EC2, runner.GetResourceIds(plan.PrivateSubnets), cluster, aws.SUBNET_TYPE_PRIVATE); err != nil {
+				return fmt.Errorf("cannot update tags of private subnets: %s", err.Error())
+			}
+			color.Green.Fprintln(out, "Step 3. Tags for Private Subnet are successfully updated")
 		}
 
-		// 5. OpenID Connector Check
-		ret, err := aws.CreateOpenIDConnector(executor.IAM, clusterInfo.Cluster.Identity.Oidc.Issuer)
+		if plan.NeedsOIDCProvider() {
+			ret, err := aws.CreateOpenIDConnector(executor.IAM, clusterInfo.Cluster.Identity.Oidc.Issuer)
+			if err != nil {
+				return fmt.Errorf("cannot create OIDC provider: %s", err.Error())
+			}
 
-		if ret == aws.ALREADY_EXISTS {
-			color.Blue.Fprintln(out, "Step 4. OIDC Provider already exists")
-		} else if ret == aws.NEWLY_CREATED {
-			color.Red.Fprintln(out, "Step 4. New OIDC Provider is successfully created")
-		} else {
-			return err
+			if ret == aws.ALREADY_EXISTS {
+				color.Blue.Fprintln(out, "Step 4. OIDC Provider already exists")
+			} else {
+				color.Green.Fprintln(out, "Step 4. New OIDC Provider is successfully created")
+			}
 		}
 
+		color.Green.Fprintln(out, fmt.Sprintf("%s has been initialized", cluster))
 		return nil
 	})
 }
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+)
+
+var (
+	OUTPUT_TABLE = "table"
+	OUTPUT_JSON  = "json"
+)
+
+// Create Command for checking readiness of cluster upgrade
+func NewCmdUpgradeCheckCluster() *cobra.Command {
+	return NewCmd("upgrade-check").
+		WithDescription("Check readiness of EKS control plane upgrade").
+		WithLongDescription(`Check readiness of EKS control plane upgrade to the target version.
+
+Report covers target version, add-on compatibility, nodegroup version skew,
+objects using deprecated or removed APIs, pod disruption budgets blocking
+node drain and available IP addresses of cluster subnets.
+Target version is the next minor version by default.
+Use -o json to attach the report to change requests and --exit-code to fail
+if any check is failed or cannot be run.`).
+		RunWithNoArgs(execUpgradeCheckCluster)
+}
+
+// Function for upgrade-check cluster command
+func execUpgradeCheckCluster(ctx context.Context, out io.Writer) error {
+	output := viper.GetString("output")
+	if output != OUTPUT_TABLE && output != OUTPUT_JSON {
+		return fmt.Errorf("--output should be one of %s, %s", OUTPUT_TABLE, OUTPUT_JSON)
+	}
+
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		// Nodes and objects are checked with kubernetes client of the cluster
+		if err := executor.CheckEKSClusterContext(); err != nil {
+			return err
+		}
+
+		clusterInfo, err := aws.GetClusterInfo(executor.EKS, executor.EKSCluster.Name)
+		if err != nil {
+			return err
+		}
+
+		report := runner.NewUpgradeReport(clusterInfo.Cluster, viper.GetString("target-version"))
+		if output == OUTPUT_TABLE {
+			color.Blue.Fprintln(out, fmt.Sprintf("Checking upgrade of %s from %s to %s", report.Cluster, report.CurrentVersion, report.TargetVersion))
+		}
+
+		// 1. Target version
+		runner.CheckTargetVersion(report)
+
+		// 2. Add-ons
+		runner.CheckAddonCompatibility(report, executor.EKS)
+
+		// 3. Nodegroups and nodes
+		nodegroups, err := runner.GetAllNodegroups(executor.EKS, report.Cluster)
+		if err != nil {
+			report.AddError(runner.UPGRADE_CHECK_NODEGROUPS, err)
+		} else {
+			runner.CheckNodegroupSkew(report, nodegroups)
+		}
+
+		nodes, err := executor.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			report.AddError(runner.UPGRADE_CHECK_NODES, err)
+		} else {
+			runner.CheckNodeVersions(report, nodes.Items)
+		}
+
+		// 4. Deprecated APIs and pod disruption budgets
+		mapper, err := runner.GetRESTMapper(executor.Config)
+		if err != nil {
+			report.AddError(runner.UPGRADE_CHECK_DEPRECATED_APIS, err)
+			report.AddError(runner.UPGRADE_CHECK_PDBS, err)
+		} else {
+			runner.CheckDeprecatedAPIs(ctx, report, executor.Client, executor.Dynamic, mapper)
+			runner.CheckPodDisruptionBudgets(ctx, report, executor.Dynamic, mapper)
+		}
+
+		// 5. Subnets
+		if vpcConfig := clusterInfo.Cluster.ResourcesVpcConfig; vpcConfig != nil {
+			runner.CheckSubnetCapacity(report, executor.EC2, vpcConfig.SubnetIds)
+		}
+
+		if output == OUTPUT_JSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			runner.RenderUpgradeReport(report)
+			for _, reportErr := range report.Errors {
+				color.Red.Fprintln(out, reportErr)
+			}
+		}
+
+		if report.HasFailure() {
+			if output == OUTPUT_TABLE {
+				color.Red.Fprintln(out, fmt.Sprintf("%s is not ready to be upgraded to %s", report.Cluster, report.TargetVersion))
+			}
+
+			if viper.GetBool("exit-code") {
+				return fmt.Errorf("upgrade check of %s failed", report.Cluster)
+			}
+			return nil
+		}
+
+		if output == OUTPUT_TABLE {
+			color.Green.Fprintln(out, fmt.Sprintf("%s is ready to be upgraded to %s", report.Cluster, report.TargetVersion))
+		}
+		return nil
+	})
+}
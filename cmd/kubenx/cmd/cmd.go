@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -30,10 +32,17 @@ You can find more information in https://github.com/GwonsooLee/kubenx`,
 			return cmd.Help()
 		},
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			return nil
+			resolveContextFlag()
+			return checkCurrentContext(err, cmd)
 		},
 	}
 
+	// Flags for loading kubeconfig are honoured by every command
+	rootCmd.PersistentFlags().StringVar(&runner.KubeconfigPath, "kubeconfig", "", "Path to the kubeconfig file to use instead of KUBECONFIG")
+	rootCmd.PersistentFlags().StringVar(&runner.ContextName, "context", "", "Name or alias of the kubeconfig context to use")
+	rootCmd.PersistentFlags().StringP("namespace", "n", "", "Namespace to use instead of the one in kubeconfig")
+	viper.BindPFlag("namespace", rootCmd.PersistentFlags().Lookup("namespace"))
+
 	//Group by commands
 	groups := templates.CommandGroups{
 		{
@@ -42,12 +51,16 @@ You can find more information in https://github.com/GwonsooLee/kubenx`,
 				NewCmdGet(),
 				NewCmdSearch(),
 				NewCmdInspect(),
+				NewCmdSecret(),
+				NewCmdScan(),
 			},
 		},
 		{
 			Message: "manager EKS Cluster",
 			Commands: []*cobra.Command{
 				NewCmdCluster(),
+				NewCmdNodegroup(),
+				NewCmdAddon(),
 				NewCmdConfig(),
 			},
 		},
@@ -58,6 +71,7 @@ You can find more information in https://github.com/GwonsooLee/kubenx`,
 	rootCmd.AddCommand(NewCmdPortForward())
 	rootCmd.AddCommand(NewCmdNamespace())
 	rootCmd.AddCommand(NewCmdContext())
+	rootCmd.AddCommand(NewCmdToken())
 	rootCmd.AddCommand(NewCmdCompletion())
 	rootCmd.AddCommand(NewCmdVersion())
 
@@ -92,6 +106,13 @@ func initConfig() {
 	}
 }
 
+// Context flag could be an alias in kubenx configuration
+func resolveContextFlag() {
+	if len(runner.ContextName) > 0 {
+		runner.ContextName = aws.ResolveContextAlias(runner.ContextName)
+	}
+}
+
 func alwaysSucceedWhenCancelled(ctx context.Context, err error) error {
 	// if the context was cancelled act as if all is well
 	if err != nil && ctx.Err() == context.Canceled {
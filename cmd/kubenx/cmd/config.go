@@ -2,18 +2,19 @@ package cmd
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/GwonsooLee/kubenx/pkg/aws"
 	"github.com/GwonsooLee/kubenx/pkg/color"
 	"github.com/GwonsooLee/kubenx/pkg/runner"
 	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"io"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
+	"os"
 	"strings"
 )
 
@@ -40,6 +41,18 @@ func NewCmdConfigInit() *cobra.Command {
 		RunWithNoArgs(execInitConfig)
 }
 
+//Sync config command
+func NewCmdConfigSync() *cobra.Command {
+	return NewCmd("sync").
+		WithDescription("Sync kubeconfig with EKS clusters of all assume roles").
+		WithLongDescription(`Sync kubeconfig with EKS clusters of all assume roles.
+
+Contexts of new clusters are added, and endpoint or certificate authority of existing ones are updated.
+Contexts written by kubenx are pruned if their clusters do not exist anymore.
+Changes are shown first, and applied after confirmation.`).
+		RunWithNoArgs(execSyncConfig)
+}
+
 //Delete config command
 func NewCmdConfigDelete() *cobra.Command {
 	return NewCmd("delete").
@@ -51,7 +64,7 @@ func NewCmdConfigDelete() *cobra.Command {
 // Function for Config execution
 func execConfig(_ context.Context, out io.Writer) error {
 	// Get Current Config
-	configAccess := clientcmd.NewDefaultPathOptions()
+	configAccess := runner.GetConfigAccess()
 	config, err := configAccess.GetStartingConfig()
 	if err != nil {
 		return err
@@ -67,7 +80,8 @@ func execConfig(_ context.Context, out io.Writer) error {
 
 // Function for update configuration in kubeconfig
 func execUpdateConfig(ctx context.Context, out io.Writer, args []string) error {
-	return runExecutorWithAWS(ctx, func(executor Executor) error {
+	// Kubeconfig could be empty, so executor is not used
+	return runWithoutExecutor(ctx, func() error {
 		var cluster string
 
 		// 1. Check Cluster
@@ -75,84 +89,83 @@ func execUpdateConfig(ctx context.Context, out io.Writer, args []string) error {
 			cluster = args[0]
 		}
 
-		if len(cluster) == 0 {
-			clusters := runner.GetEKSClusterList(executor.EKS)
-			prompt := &survey.Select{
-				Message: "Choose a cluster:",
-				Options: clusters,
-			}
-			survey.AskOne(prompt, &cluster)
+		// Cluster in the region of --region is updated directly with assume role mapped to the context
+		if len(cluster) > 0 && len(viper.GetStringSlice("regions")) == 0 {
+			region := viper.GetString("region")
+			role := getAssumeRoleForContext(cluster)
+			return updateKubeConfig(aws.GetEksSessionInRegion(runner.GetRoleReference(role), region), out, cluster, region, role)
 		}
 
-		// if cluster is not set
-		if cluster == "" {
-			color.Red.Fprintln(out, fmt.Sprintf("No cluster exists in %s region...", viper.GetString("region")))
-			return nil
-		}
-
-		// Get Current Config
-		configAccess := clientcmd.NewDefaultPathOptions()
-		config, err := configAccess.GetStartingConfig()
+		// Clusters are searched with assume roles like config init and sync
+		kubeEKSConfig, err := aws.FindEKSAussmeInfo()
 		if err != nil {
 			return err
 		}
 
-		// 2. Get Cluster Information
-		clusterInfo, err := aws.GetClusterInfo(executor.EKS, cluster)
-		if err != nil {
-			return err
+		roles := getAssumeReferenceList(out, kubeEKSConfig)
+		if len(roles) == 0 {
+			roles = []string{utils.NO_STRING}
+		} else {
+			aws.ResetAWSEnvironmentVariable()
 		}
 
-		arn := *clusterInfo.Cluster.Arn
-		name := *clusterInfo.Cluster.Name
+		locations, _, errs := runner.ListEKSClustersInRegions(roles)
+		printErrors(out, errs)
 
-		//Check existing cluster
-		isUpdated := false
-		for _, c := range config.Clusters {
-			if c.Server == *clusterInfo.Cluster.Endpoint {
-				color.Red.Fprintln(out, fmt.Sprintf("%s config already exist", name))
-				isUpdated = true
+		labels := []string{}
+		targets := map[string]runner.EKSClusterLocation{}
+		for _, location := range locations {
+			if len(cluster) > 0 && cluster != location.Name {
+				continue
 			}
-		}
 
-		newCluster := api.NewCluster()
-		decoded, _ := base64.StdEncoding.DecodeString(*clusterInfo.Cluster.CertificateAuthority.Data)
-		newCluster.CertificateAuthorityData = decoded
-		newCluster.Server = *clusterInfo.Cluster.Endpoint
-
-		newAuthInfo := api.NewAuthInfo()
-		newAuthInfo.Exec = &api.ExecConfig{
-			Command:    utils.AUTH_COMMAND,
-			Args:       []string{"--region", viper.GetString("region"), "eks", "get-token", "--cluster-name", name},
-			APIVersion: utils.AUTH_API_VERSION,
+			label := fmt.Sprintf("%s (%s)", location.Name, location.Region)
+			if len(location.Account) > 0 {
+				label = fmt.Sprintf("%s (%s, %s)", location.Name, location.Region, location.Account)
+			}
+			if _, ok := targets[label]; ok {
+				continue
+			}
+			labels = append(labels, label)
+			targets[label] = location
 		}
 
-		newContext := api.NewContext()
-		newContext.Cluster = arn
-		newContext.AuthInfo = arn
-
-		config.Clusters[arn] = newCluster
-		config.AuthInfos[arn] = newAuthInfo
-		config.Contexts[name] = newContext
+		// if cluster is not found
+		if len(labels) == 0 {
+			color.Red.Fprintln(out, "No cluster exists in target regions...")
+			return nil
+		}
 
-		if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
-			return err
+		// Same cluster name could exist in several regions
+		label := labels[0]
+		if len(labels) > 1 {
+			label = utils.NO_STRING
+			prompt := &survey.Select{
+				Message: "Choose a cluster:",
+				Options: labels,
+			}
+			survey.AskOne(prompt, &label)
 		}
 
-		if isUpdated {
-			color.Blue.Fprintln(out, fmt.Sprintf("Update existing context %s", name))
-		} else {
-			color.Blue.Fprintln(out, fmt.Sprintf("Create new context %s", name))
+		target, ok := targets[label]
+		if !ok {
+			color.Red.Fprintln(out, "No cluster has been selected")
+			return nil
 		}
 
-		return nil
+		// 2. Update configuration with the role which found the cluster, or assume role mapped to the context
+		role := target.Role
+		if len(role) == 0 {
+			role = getAssumeRoleForContext(target.Name)
+		}
+		return updateKubeConfig(aws.GetEksSessionInRegion(runner.GetRoleReference(role), target.Region), out, target.Name, target.Region, role)
 	})
 }
 
 // Function for delete configuration in kubeconfig
 func execDeleteConfig(ctx context.Context, out io.Writer, cmd *cobra.Command, args []string) error {
 	return runExecutor(ctx, func(executor Executor) error {
-		configAccess := clientcmd.NewDefaultPathOptions()
+		configAccess := runner.GetConfigAccess()
 
 		deleteClusterConfig(out, configAccess, cmd)
 		return nil
@@ -167,102 +180,347 @@ func execInitConfig(ctx context.Context, out io.Writer) error {
 		return err
 	}
 
-	assumeList := []string{}
-	for env, role := range kubeEKSConfig.Assume {
-		color.Blue.Fprintf(out, "assume role added : %s", env)
-		assumeList = append(assumeList, role)
-	}
+	assumeList := getAssumeReferenceList(out, kubeEKSConfig)
 
 	if len(assumeList) == 0 {
 		color.Yellow.Fprintln(out, "no assume role exists. only init with current configuration.")
-		return runExecutorWithAWS(ctx, func(executor Executor) error {
-			clusters := runner.GetEKSClusterList(executor.EKS)
+		assumeList = []string{utils.NO_STRING}
+	} else {
+		aws.ResetAWSEnvironmentVariable()
+	}
 
-			for _, cluster := range clusters {
-				if err := updateKubeConfig(executor, out, cluster); err != nil {
-					return err
-				}
+	// Kubeconfig could be empty, so executor is not used
+	return runWithoutExecutor(ctx, func() error {
+		locations, _, errs := runner.ListEKSClustersInRegions(assumeList)
+		clusters, describeErrs := runner.DescribeEKSClusters(locations)
+		errs = append(errs, describeErrs...)
+
+		if err := setContextNames(out, clusters); err != nil {
+			return err
+		}
+
+		configAccess := runner.GetUserConfigAccess()
+		config, err := configAccess.GetStartingConfig()
+		if err != nil {
+			return err
+		}
+
+		results := []runner.ClusterResult{}
+		for _, cluster := range clusters {
+			status := runner.CLUSTER_STATUS_CREATED
+			if _, ok := config.Clusters[cluster.Arn]; ok {
+				status = runner.CLUSTER_STATUS_UPDATED
 			}
 
+			setClusterConfig(config, cluster)
+			results = append(results, runner.ClusterResult{
+				Account: cluster.Account,
+				Region:  cluster.Region,
+				Cluster: cluster.Name,
+				Context: cluster.ContextName,
+				Role:    cluster.Role,
+				Status:  status,
+			})
+		}
+
+		for _, err := range errs {
+			results = append(results, runner.GetClusterResultOfError(err))
+		}
+
+		// Kubeconfig is written once with all clusters described successfully
+		if len(clusters) > 0 {
+			if err := runner.WriteConfigAtomically(configAccess, *config); err != nil {
+				return err
+			}
+		}
+
+		if !runner.RenderClusterResults(results) {
+			color.Yellow.Fprintln(out, "No cluster exists in target regions")
+		}
+
+		failedClusters, failedScopes := runner.GetFailedTargets(results)
+		failures := []string{}
+		if len(failedClusters) > 0 {
+			failures = append(failures, fmt.Sprintf("%d clusters failed: %s", len(failedClusters), strings.Join(failedClusters, ", ")))
+		}
+		if len(failedScopes) > 0 {
+			failures = append(failures, fmt.Sprintf("%d accounts or regions failed: %s", len(failedScopes), strings.Join(failedScopes, ", ")))
+		}
+		if len(failures) > 0 {
+			return fmt.Errorf("%s. Other clusters are written to kubeconfig", strings.Join(failures, "; "))
+		}
+
+		return nil
+	})
+}
+
+// Function for syncing kubeconfig with EKS clusters
+func execSyncConfig(ctx context.Context, out io.Writer) error {
+	// Kubeconfig could be empty, so executor is not used
+	return runWithoutExecutor(ctx, func() error {
+		clusters, scanned, err := getAllEKSClusters(out)
+		if err != nil {
+			return err
+		}
+
+		configAccess := runner.GetUserConfigAccess()
+		config, err := configAccess.GetStartingConfig()
+		if err != nil {
+			return err
+		}
+
+		changes := runner.DiffKubeconfig(config, clusters, scanned)
+		if !runner.RenderConfigChanges(changes) {
+			color.Green.Fprintln(out, "kubeconfig is already in sync with EKS clusters")
 			return nil
-		})
-	} else {
-		aws.ResetAWSEnvironmentVariable()
-	}
+		}
 
-	return runExecutorWithAWSAssume(ctx, assumeList, func(executor Executor, assumeRoleList []string) error {
-		for _, role := range assumeRoleList {
-			//Set AWS sessions
-			executor.EKS = aws.GetEksSession(&role)
-			executor.EC2 = aws.GetEC2Session(&role)
-			executor.IAM = aws.GetIAMSession(&role)
+		if viper.GetBool("dry-run") {
+			return nil
+		}
 
-			clusters := runner.GetEKSClusterList(executor.EKS)
+		if !viper.GetBool("yes") {
+			confirmed := false
+			prompt := &survey.Confirm{Message: fmt.Sprintf("Apply %d changes to kubeconfig?", len(changes))}
+			if err := survey.AskOne(prompt, &confirmed, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+				return err
+			}
 
-			for _, cluster := range clusters {
-				if err := updateKubeConfig(executor, out, cluster); err != nil {
-					return err
-				}
+			if !confirmed {
+				color.Red.Fprintln(out, "Sync has been canceled")
+				return nil
 			}
 		}
 
+		runner.ApplyConfigChanges(config, changes, setClusterConfig)
+		if err := runner.WriteConfigAtomically(configAccess, *config); err != nil {
+			return err
+		}
+
+		color.Blue.Fprintln(out, fmt.Sprintf("%d changes are applied to kubeconfig", len(changes)))
 		return nil
 	})
 }
 
-func updateKubeConfig(executor Executor, out io.Writer, cluster string) error {
+// Get EKS clusters with all assume roles, and scopes of region and account searched successfully.
+// Roles failed to search are skipped, so contexts of them are not pruned
+func getAllEKSClusters(out io.Writer) ([]runner.EKSClusterConfig, map[string]bool, error) {
+	kubeEKSConfig, err := aws.FindEKSAussmeInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Current credentials are used if no assume role exists
+	roles := getAssumeReferenceList(out, kubeEKSConfig)
+	if len(roles) == 0 {
+		roles = []string{utils.NO_STRING}
+	} else {
+		aws.ResetAWSEnvironmentVariable()
+	}
+
+	locations, scanned, errs := runner.ListEKSClustersInRegions(roles)
+	printErrors(out, errs)
+
+	if len(scanned) == 0 && len(errs) > 0 {
+		return nil, nil, fmt.Errorf("no cluster could be searched with assume roles")
+	}
+
+	clusters, describeErrs := runner.DescribeEKSClusters(locations)
+	printErrors(out, describeErrs)
+
+	if err := setContextNames(out, clusters); err != nil {
+		return nil, nil, err
+	}
+
+	// Clusters not described could be mistaken for removed ones
+	for _, err := range describeErrs {
+		if discoveryErr, ok := err.(*runner.EKSDiscoveryError); ok {
+			delete(scanned, runner.GetClusterScope(discoveryErr.Region, discoveryErr.Account))
+		}
+	}
+
+	return clusters, scanned, nil
+}
+
+// Print errors of roles or regions failed to search
+func printErrors(out io.Writer, errs []error) {
+	for _, err := range errs {
+		color.Red.Fprintln(out, err.Error())
+	}
+}
+
+// Get references of all assume configurations
+func getAssumeReferenceList(out io.Writer, kubeEKSConfig aws.KubenxAussmeConfig) []string {
+	assumeList := []string{}
+	for env, role := range kubeEKSConfig.Assume {
+		color.Blue.Fprintf(out, "assume role added : %s", env)
+		assumeList = append(assumeList, getAssumeReference(env, role))
+	}
+
+	return assumeList
+}
+
+// Get assume role of the context from kubenx configuration
+func getAssumeRoleForContext(name string) string {
+	kubeEKSConfig, err := aws.FindEKSAussmeInfo()
+	if err != nil {
+		return utils.NO_STRING
+	}
+
+	alias, ok := kubeEKSConfig.EKSAssumeMapping[name]
+	if !ok {
+		return utils.NO_STRING
+	}
+
+	return getAssumeReference(alias, kubeEKSConfig.Assume[alias])
+}
+
+// Get reference of assume configuration, which is role ARN or alias of AWS SSO permission set
+func getAssumeReference(alias string, config aws.AssumeRoleConfig) string {
+	if len(config.RoleArn) > 0 {
+		return config.RoleArn
+	}
+
+	return alias
+}
+
+// Get exec configuration which generates token with kubenx
+func getExecConfig(cluster, region, role string) *api.ExecConfig {
+	args := []string{"token", "--cluster", cluster, "--region", region}
+	if strings.HasPrefix(role, "arn:") {
+		args = append(args, "--role-arn", role)
+	} else if len(role) > 0 {
+		args = append(args, "--assume", role)
+	}
+
+	return &api.ExecConfig{
+		Command:    utils.AUTH_COMMAND,
+		Args:       args,
+		APIVersion: utils.AUTH_API_VERSION,
+	}
+}
+
+// Add or update cluster configuration in kubeconfig
+func updateKubeConfig(svc *eks.EKS, out io.Writer, cluster, region, role string) error {
 	// Get Current Config
-	configAccess := clientcmd.NewDefaultPathOptions()
+	configAccess := runner.GetUserConfigAccess()
 	config, err := configAccess.GetStartingConfig()
 	if err != nil {
 		return err
 	}
 
-	clusterInfo, err := aws.GetClusterInfo(executor.EKS, cluster)
+	clusterInfo, err := aws.GetClusterInfo(svc, cluster)
 	if err != nil {
 		return err
 	}
 
-	arn := *clusterInfo.Cluster.Arn
-	name := *clusterInfo.Cluster.Name
+	clusters := []runner.EKSClusterConfig{runner.NewEKSClusterConfig(clusterInfo.Cluster, region, role)}
+	if err := setContextNames(out, clusters); err != nil {
+		return err
+	}
+	newCluster := clusters[0]
 
 	//Check existing cluster
 	isUpdated := false
 	for _, c := range config.Clusters {
-		if c.Server == *clusterInfo.Cluster.Endpoint {
-			color.Red.Fprintln(out, fmt.Sprintf("%s config already exist", name))
+		if c.Server == newCluster.Endpoint {
+			color.Red.Fprintln(out, fmt.Sprintf("%s config already exist", newCluster.ContextName))
 			isUpdated = true
 		}
 	}
 
+	setClusterConfig(config, newCluster)
+
+	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
+		return err
+	}
+
+	if isUpdated {
+		color.Blue.Fprintln(out, fmt.Sprintf("Update existing context %s", newCluster.ContextName))
+	} else {
+		color.Blue.Fprintln(out, fmt.Sprintf("Create new context %s", newCluster.ContextName))
+	}
+
+	return nil
+}
+
+// Write cluster, user and context of EKS cluster to kubeconfig
+func setClusterConfig(config *api.Config, cluster runner.EKSClusterConfig) {
 	newCluster := api.NewCluster()
-	decoded, _ := base64.StdEncoding.DecodeString(*clusterInfo.Cluster.CertificateAuthority.Data)
-	newCluster.CertificateAuthorityData = decoded
-	newCluster.Server = *clusterInfo.Cluster.Endpoint
+	newCluster.CertificateAuthorityData = cluster.CertificateAuthority
+	newCluster.Server = cluster.Endpoint
 
 	newAuthInfo := api.NewAuthInfo()
-	newAuthInfo.Exec = &api.ExecConfig{
-		Command:    utils.AUTH_COMMAND,
-		Args:       []string{"--region", viper.GetString("region"), "eks", "get-token", "--cluster-name", name},
-		APIVersion: utils.AUTH_API_VERSION,
-	}
+	newAuthInfo.Exec = getExecConfig(cluster.Name, cluster.Region, cluster.Role)
 
 	newContext := api.NewContext()
-	newContext.Cluster = arn
-	newContext.AuthInfo = arn
+	newContext.Cluster = cluster.Arn
+	newContext.AuthInfo = cluster.Arn
+
+	// Only the context kubenx wrote before for the cluster is renamed, keeping its namespace.
+	// Other contexts using the cluster are left as they are
+	if name, ok := runner.GetPreviousContextName(config, cluster); ok {
+		newContext.Namespace = config.Contexts[name].Namespace
+		if name != cluster.ContextName {
+			delete(config.Contexts, name)
+			if config.CurrentContext == name {
+				config.CurrentContext = cluster.ContextName
+			}
+		}
+	}
 
-	config.Clusters[arn] = newCluster
-	config.AuthInfos[arn] = newAuthInfo
-	config.Contexts[name] = newContext
+	if len(newContext.Namespace) == 0 {
+		newContext.Namespace = aws.FindContextMetadata(cluster.ContextName).Namespace
+	}
 
-	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
-		return err
+	config.Clusters[cluster.Arn] = newCluster
+	config.AuthInfos[cluster.Arn] = newAuthInfo
+	config.Contexts[cluster.ContextName] = newContext
+}
+
+// Set context names of clusters with the template in kubenx configuration
+func setContextNames(out io.Writer, clusters []runner.EKSClusterConfig) error {
+	// Cluster name is used without kubenx configuration
+	kubeEKSConfig, _ := aws.FindEKSAussmeInfo()
+	nameTemplate := kubeEKSConfig.ContextNameTemplate
+
+	aliases := map[string]string{}
+	names := map[string][]string{}
+	for i := range clusters {
+		cluster := &clusters[i]
+
+		if runner.IsAccountAliasUsed(nameTemplate) {
+			alias, ok := aliases[cluster.Account]
+			if !ok {
+				var err error
+				alias, err = aws.GetAccountAlias(aws.GetIAMSession(runner.GetRoleReference(cluster.Role)))
+				if err != nil {
+					color.Yellow.Fprintln(out, fmt.Sprintf("cannot get alias of account %s, account ID is used: %s", cluster.Account, err.Error()))
+				}
+				aliases[cluster.Account] = alias
+			}
+			cluster.AccountAlias = alias
+		}
+
+		name, err := runner.RenderContextName(nameTemplate, *cluster)
+		if err != nil {
+			return err
+		}
+
+		if !utils.IsStringInArray(cluster.Arn, names[name]) {
+			names[name] = append(names[name], cluster.Arn)
+		}
+		cluster.ContextName = name
 	}
 
-	if isUpdated {
-		color.Blue.Fprintln(out, fmt.Sprintf("Update existing context %s", name))
-	} else {
-		color.Blue.Fprintln(out, fmt.Sprintf("Create new context %s", name))
+	// Clusters with the same context name, e.g. clusters of the same name in several accounts,
+	// use their ARN as context name, so every cluster is written to kubeconfig
+	for i := range clusters {
+		cluster := &clusters[i]
+		if arns := names[cluster.ContextName]; len(arns) > 1 {
+			color.Yellow.Fprintln(out, fmt.Sprintf("context name %s is used for %d clusters, %s is used instead. Please check context_name_template in %s", cluster.ContextName, len(arns), cluster.Arn, aws.CONFIG_FILE_PATH))
+			cluster.ContextName = cluster.Arn
+		}
 	}
 
 	return nil
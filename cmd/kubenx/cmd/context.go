@@ -6,11 +6,18 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/GwonsooLee/kubenx/pkg/aws"
 	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/history"
 	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"io"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"os"
 	"os/exec"
+	"runtime"
 )
 
 //Create Command for get pod
@@ -18,6 +25,7 @@ func NewCmdContext() *cobra.Command {
 	return NewCmd("context").
 		WithDescription("Change context from kubeconfig").
 		SetAliases([]string{"ctx"}).
+		SetOwnFlags().
 		RunWithArgs(execContext)
 }
 
@@ -32,7 +40,7 @@ func changeContext(out io.Writer, args []string) error {
 	var newContext string
 
 	//Get API configuration
-	configs, _, err := runner.GetAPIConfig()
+	_, configAccess, err := runner.GetAPIConfig()
 	if err != nil {
 		color.Red.Fprintln(out, err.Error())
 		return err
@@ -48,9 +56,16 @@ func changeContext(out io.Writer, args []string) error {
 	//getting current Context
 	currentContext := currentConfig.CurrentContext
 
+	// History is only for convenience, so switching works without it
+	contextHistory, err := history.Load()
+	if err != nil {
+		color.Yellow.Fprintln(out, fmt.Sprintf("cannot load history: %s", err.Error()))
+		contextHistory = &history.History{}
+	}
+
 	if len(args) == 0 {
 		// get list of context
-		for context, _ := range configs.Contexts {
+		for context, _ := range currentConfig.Contexts {
 			contextList = append(contextList, context)
 		}
 
@@ -58,25 +73,46 @@ func changeContext(out io.Writer, args []string) error {
 		color.Red.Fprintln(out, fmt.Sprintf("Current Context: %s", currentContext))
 		prompt := &survey.Select{
 			Message: "Choose Context:",
-			Options: contextList,
+			Options: history.SortByRecent(contextList, contextHistory.RecentContexts()),
 		}
-		survey.AskOne(prompt, &newContext)
+		// Keep stdout clean for eval when printing credentials
+		survey.AskOne(prompt, &newContext, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr))
 
 		if newContext == "" {
 			color.Red.Fprintln(out, fmt.Errorf("Changing Context has been canceled"))
 			return err
 		}
+	} else if args[0] == "-" {
+		previous, ok := contextHistory.PreviousContext(currentContext)
+		if !ok {
+			return fmt.Errorf("no previous context exists in history")
+		}
+		newContext = previous
 	} else {
-		newContext = args[0]
+		newContext = aws.ResolveContextAlias(args[0])
 	}
 
-	//Change To New Context
-	currentConfig.CurrentContext = newContext
-	configAccess := clientcmd.NewDefaultClientConfig(*configs, &clientcmd.ConfigOverrides{}).ConfigAccess()
+	if _, ok := currentConfig.Contexts[newContext]; !ok {
+		return fmt.Errorf("context %s doesn't exist in kubeconfig", newContext)
+	}
 
-	clientcmd.ModifyConfig(configAccess, *currentConfig, false)
+	//Change To New Context only in the shell if session is used
+	isSession, err := switchSessionContext(out, currentConfig, newContext, utils.NO_STRING)
+	if err != nil {
+		return err
+	}
+
+	if !isSession {
+		currentConfig.CurrentContext = newContext
+		clientcmd.ModifyConfig(configAccess, *currentConfig, false)
+	}
 	color.Yellow.Fprintln(out, fmt.Sprintf("Context is changed to %s", newContext))
 
+	contextHistory.AddContext(currentContext, newContext)
+	if err := contextHistory.Save(); err != nil {
+		color.Yellow.Fprintln(out, fmt.Sprintf("cannot save history: %s", err.Error()))
+	}
+
 	//Assume Role
 	kubeEKSConfig, err := aws.FindEKSAussmeInfo()
 	if err != nil {
@@ -88,38 +124,94 @@ func changeContext(out io.Writer, args []string) error {
 		return nil
 	}
 
-	assumeCreds := aws.AssumeRole(kubeEKSConfig.Assume[kubeEKSConfig.EKSAssumeMapping[newContext]], kubeEKSConfig.SessionName)
+	assumeCreds, err := aws.AssumeRole(kubeEKSConfig.EKSAssumeMapping[newContext], aws.GetAssumeSessionName())
+	if err != nil {
+		color.Red.Fprintln(out, err.Error())
+		return err
+	}
 
-	pbcopy := exec.Command("pbcopy")
-	in, _ := pbcopy.StdinPipe()
+	return deliverCredentials(out, newContext, assumeCreds)
+}
 
-	if err := pbcopy.Start(); err != nil {
-		return err
+// Write context and namespace only to session kubeconfig of the shell.
+// Session is started with --session, and used until the shell exits.
+func switchSessionContext(out io.Writer, merged *api.Config, contextName, namespace string) (bool, error) {
+	path, ok := runner.GetSessionKubeconfig()
+	if !ok && !viper.GetBool("session") {
+		return false, nil
 	}
 
-	if _, err := in.Write([]byte(fmt.Sprintf("export AWS_ACCESS_KEY_ID=%s\n", *assumeCreds.AccessKeyId))); err != nil {
-		return err
+	// Session kubeconfig removed after the shell exited is created again
+	if ok && runner.CheckSessionKubeconfig() != nil {
+		ok = false
 	}
 
-	if _, err := in.Write([]byte(fmt.Sprintf("export AWS_SECRET_ACCESS_KEY=%s\n", *assumeCreds.SecretAccessKey))); err != nil {
-		return err
+	if !ok {
+		sessionPath, kubeconfig, err := runner.CreateSessionKubeconfig()
+		if err != nil {
+			return false, err
+		}
+		path = sessionPath
+
+		// Export should be evaluated by shell, e.g. eval $(kubenx ctx prod --session)
+		fmt.Printf("export KUBECONFIG=%s\n", kubeconfig)
+		color.Blue.Fprintln(out, fmt.Sprintf("Session kubeconfig is created in %s", path))
 	}
 
-	if _, err := in.Write([]byte(fmt.Sprintf("export AWS_SESSION_TOKEN=%s\n", *assumeCreds.SessionToken))); err != nil {
-		return err
+	return true, runner.WriteSessionContext(path, *merged, contextName, namespace)
+}
+
+// Deliver assumed credentials in the way user chose
+func deliverCredentials(out io.Writer, contextName string, creds *sts.Credentials) error {
+	exports := aws.GetCredentialExports(creds)
+
+	if viper.GetBool("print-env") {
+		fmt.Print(exports)
+		return nil
 	}
 
-	if err := in.Close(); err != nil {
-		return err
+	if profile := viper.GetString("profile"); len(profile) > 0 {
+		if err := aws.WriteCredentialsProfile(profile, creds); err != nil {
+			return err
+		}
+		color.Blue.Fprintln(out, fmt.Sprintf("Assume Credentials are written to profile %s in %s", profile, aws.GetSharedCredentialsFilePath()))
+		return nil
 	}
 
-	err = pbcopy.Wait()
-	if err != nil {
-		color.Red.Fprintln(out, err.Error())
-		return err
+	if viper.GetBool("shell") {
+		return runCredentialShell(out, contextName, creds)
+	}
+
+	if err := utils.CopyToClipboard(exports); err != nil {
+		color.Red.Fprintln(out, fmt.Sprintf("Cannot copy to clipboard: %s", err.Error()))
+		color.Blue.Fprintln(out, "Please run the commands below, or use --print-env with eval.")
+		fmt.Print(exports)
+		return nil
 	}
 
 	color.Blue.Fprintln(out, "Assume Credentials copied to clipboard, please paste it.")
 
 	return nil
 }
+
+// Run subshell with assumed credentials
+func runCredentialShell(out io.Writer, contextName string, creds *sts.Credentials) error {
+	shell := os.Getenv("SHELL")
+	if len(shell) == 0 {
+		shell = "/bin/sh"
+		if runtime.GOOS == "windows" {
+			shell = "cmd.exe"
+		}
+	}
+
+	color.Blue.Fprintln(out, fmt.Sprintf("Starting %s with credentials for %s. Exit the shell to drop them.", shell, contextName))
+
+	subshell := exec.Command(shell)
+	subshell.Env = append(os.Environ(), aws.GetCredentialEnvs(creds)...)
+	subshell.Env = append(subshell.Env, fmt.Sprintf("KUBENX_CONTEXT=%s", contextName))
+	subshell.Stdin = os.Stdin
+	subshell.Stdout = os.Stdout
+	subshell.Stderr = os.Stderr
+
+	return subshell.Run()
+}
@@ -2,25 +2,34 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"github.com/GwonsooLee/kubenx/pkg/aws"
 	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	v1beta1 "k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
 	rbacv1 "k8s.io/client-go/kubernetes/typed/rbac/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 type Executor struct {
 	Client       *kubernetes.Clientset
 	BetaV1Client *v1beta1.ExtensionsV1beta1Client
 	RbacV1Client *rbacv1.RbacV1Client
+	Dynamic      dynamic.Interface
 	EKS          *eks.EKS
 	EC2          *ec2.EC2
 	IAM          *iam.IAM
+	AutoScaling  *autoscaling.AutoScaling
+	EKSCluster   runner.EKSClusterLocation
 	Config       *rest.Config
+	ClientConfig clientcmd.ClientConfig
 	Namespace    string
 	Context      context.Context
 }
@@ -40,15 +49,29 @@ func runExecutor(ctx context.Context, action func(Executor) error) error {
 
 // Run executor for command line
 func runExecutorWithAWS(ctx context.Context, action func(Executor) error) error {
-	executor, err := createNewExecutor()
+	cluster, err := getTargetEKSCluster()
+	if err != nil {
+		return err
+	}
+
+	// Banner and protection are checked with the context of the target cluster
+	if err := checkTargetClusterContext(cluster); err != nil {
+		return err
+	}
+
+	// Kubernetes clients are created with the context of the target cluster
+	clientConfig := runner.GetClientConfig()
+	if len(cluster.Context) > 0 {
+		clientConfig = runner.GetClientConfigOfContext(cluster.Context)
+	}
+
+	executor, err := createNewExecutorOfContext(clientConfig)
 	if err != nil {
 		return err
 	}
 
-	//Set AWS sessions
-	executor.EKS = aws.GetEksSession(nil)
-	executor.EC2 = aws.GetEC2Session(nil)
-	executor.IAM = aws.GetIAMSession(nil)
+	//Set AWS sessions for the target cluster
+	executor.SetEKSCluster(cluster)
 
 	//Run function with executor
 	err = action(executor)
@@ -56,24 +79,48 @@ func runExecutorWithAWS(ctx context.Context, action func(Executor) error) error
 	return alwaysSucceedWhenCancelled(ctx, err)
 }
 
-// run AWS with assume
-func runExecutorWithAWSAssume(ctx context.Context, assumeRoleList []string, action func(Executor, []string) error) error {
-	executor, err := createNewExecutor()
-	if err != nil {
-		return err
+// Set EKS cluster and AWS sessions with the role in the region of the cluster
+func (e *Executor) SetEKSCluster(cluster runner.EKSClusterLocation) {
+	role := runner.GetRoleReference(cluster.Role)
+
+	e.EKSCluster = cluster
+	e.EKS = aws.GetEksSessionInRegion(role, cluster.Region)
+	e.EC2 = aws.GetEC2SessionInRegion(role, cluster.Region)
+	e.AutoScaling = aws.GetAutoscalingSessionInRegion(role, cluster.Region)
+	e.IAM = aws.GetIAMSession(role)
+}
+
+// Check whether kubernetes clients of the executor are connected to the target EKS cluster.
+// Clients use current context if kubeconfig has no context of the cluster given by --cluster flag
+func (e *Executor) CheckEKSClusterContext() error {
+	if len(e.EKSCluster.Context) == 0 {
+		return fmt.Errorf("kubeconfig has no context of cluster %s in %s, please run `kubenx config update` first", e.EKSCluster.Name, e.EKSCluster.Region)
 	}
 
-	//Run function with executor
-	err = action(executor, assumeRoleList)
+	return nil
+}
 
-	return alwaysSucceedWhenCancelled(ctx, err)
+// Get EKS cluster from --cluster flag, or from current context if not set
+func getTargetEKSCluster() (runner.EKSClusterLocation, error) {
+	if cluster := viper.GetString("cluster"); len(cluster) > 0 {
+		return runner.FindEKSCluster(cluster)
+	}
+
+	return runner.GetCurrentEKSCluster()
 }
 
 // Create new executor
 func createNewExecutor() (Executor, error) {
+	return createNewExecutorOfContext(runner.GetClientConfig())
+}
+
+// Create new executor with clients of the client configuration
+func createNewExecutorOfContext(clientConfig clientcmd.ClientConfig) (Executor, error) {
 	executor := Executor{}
 
-	config, err := runner.GetConfigFromFlag()
+	// Every client is created from the same kubeconfig loader
+	executor.ClientConfig = clientConfig
+	config, err := executor.ClientConfig.ClientConfig()
 
 	executor.Config = config
 	if err != nil {
@@ -104,6 +151,14 @@ func createNewExecutor() (Executor, error) {
 
 	executor.RbacV1Client = rbacv1clientset
 
+	// create the dynamic client for arbitrary resources
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return executor, err
+	}
+
+	executor.Dynamic = dynamicClient
+
 	//Get Namespace
 	namespace, err := runner.GetNamespace()
 	if err != nil {
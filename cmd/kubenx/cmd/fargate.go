@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/spf13/cobra"
+	"io"
+)
+
+// Create Command for get fargate profile
+func NewCmdGetFargateProfile() *cobra.Command {
+	return NewCmd("fargateprofile").
+		WithDescription("Get fargate profile list").
+		SetAliases([]string{"fargateprofiles", "fp"}).
+		RunWithNoArgs(execGetFargateProfile)
+}
+
+// Function for get fargate profile command
+func execGetFargateProfile(ctx context.Context, out io.Writer) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		cluster := executor.EKSCluster.Name
+
+		profiles, err := runner.GetAllFargateProfiles(executor.EKS, cluster)
+		if err != nil {
+			return err
+		}
+
+		if !runner.RenderFargateProfileListInfo(profiles) {
+			color.Red.Fprintln(out, fmt.Sprintf("No fargate profile exists in %s", cluster))
+		}
+
+		return nil
+	})
+}
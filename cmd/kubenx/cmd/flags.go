@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"github.com/GwonsooLee/kubenx/pkg/runner"
 	"github.com/GwonsooLee/kubenx/pkg/utils"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"reflect"
+	"time"
 )
 
 // This part of code comes from Kubenx opensource.
@@ -27,15 +29,6 @@ type Flag struct {
 
 // FlagRegistry is a list of all Kubenx CLI flags.
 var FlagRegistry = []Flag{
-	{
-		Name:          "namespace",
-		Shorthand:     "n",
-		Usage:         "Run deployments in the specified namespace",
-		Value:         aws.String(utils.NO_STRING),
-		DefValue:      "",
-		FlagAddMethod: "StringVar",
-		DefinedOn:     []string{"pod", "deployment", "service", "serviceaccount", "configmap", "ingress", "role", "rolebinding", "secret"},
-	},
 	{
 		Name:          "region",
 		Shorthand:     "r",
@@ -43,16 +36,291 @@ var FlagRegistry = []Flag{
 		Value:         aws.String(utils.NO_STRING),
 		DefValue:      "ap-northeast-2",
 		FlagAddMethod: "StringVar",
-		DefinedOn:     []string{"cluster", "init", "update"},
+		DefinedOn:     []string{"cluster", "nodegroup", "fargateprofile", "scale", "upgrade", "upgrade-check", "addon list", "addon describe", "init", "update", "sync", "token"},
 	},
 	{
 		Name:          "all",
 		Shorthand:     "A",
-		Usage:         "All namespace flag",
+		Usage:         "All namespace flag, or all clusters in the region for cluster command",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"pod", "deployment", "service", "serviceaccount", "configmap", "ingress", "role", "clusterrole", "rolebinding", "clusterrolebinding", "secret", "refs", "cluster"},
+	},
+	{
+		Name:          "reveal",
+		Usage:         "Show secret values without masking",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"view"},
+	},
+	{
+		Name:          "days",
+		Usage:         "Report certificates expiring within the given days",
+		Value:         aws.Int(0),
+		DefValue:      30,
+		FlagAddMethod: "IntVar",
+		DefinedOn:     []string{"certs"},
+	},
+	{
+		Name:          "exit-code",
+		Usage:         "Exit with non-zero code if anything is found",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"certs", "upgrade-check", "deprecated-apis"},
+	},
+	{
+		Name:          "target-version",
+		Usage:         "Kubernetes version to upgrade to, the next minor version by default",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"upgrade-check", "deprecated-apis"},
+	},
+	{
+		Name:          "output",
+		Shorthand:     "o",
+		Usage:         "Output format of report, table or json",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "table",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"upgrade-check", "deprecated-apis"},
+	},
+	{
+		Name:          "print-env",
+		Usage:         "Print export lines of credentials for eval",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"context"},
+	},
+	{
+		Name:          "profile",
+		Usage:         "Write credentials to the named profile in AWS shared credentials file",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"context"},
+	},
+	{
+		Name:          "shell",
+		Usage:         "Start subshell with credentials",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"context"},
+	},
+	{
+		Name:          "regions",
+		Usage:         "Regions to discover clusters in, or all-enabled for every enabled region",
+		Value:         &[]string{},
+		DefValue:      []string{},
+		FlagAddMethod: "StringSliceVar",
+		DefinedOn:     []string{"config init", "config update", "sync"},
+	},
+	{
+		Name:          "concurrency",
+		Usage:         "Number of concurrent requests for discovering clusters or searching contexts",
+		Value:         aws.Int(0),
+		DefValue:      runner.DEFAULT_AWS_CONCURRENCY,
+		FlagAddMethod: "IntVar",
+		DefinedOn:     []string{"config init", "config update", "sync", "image"},
+	},
+	{
+		Name:          "dry-run",
+		Usage:         "Show changes without applying them",
 		Value:         aws.Bool(false),
 		DefValue:      false,
 		FlagAddMethod: "BoolVar",
-		DefinedOn:     []string{"pod", "deployment", "service", "serviceaccount", "configmap", "ingress", "role", "clusterrole", "rolebinding", "clusterrolebinding", "secret"},
+		DefinedOn:     []string{"sync", "cluster init"},
+	},
+	{
+		Name:          "yes",
+		Shorthand:     "y",
+		Usage:         "Apply changes without confirmation",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"sync", "upgrade", "addon update", "cluster init"},
+	},
+	{
+		Name:          "min",
+		Usage:         "Minimum size of nodegroup",
+		Value:         aws.Int64(0),
+		DefValue:      runner.NODEGROUP_SIZE_UNSET,
+		FlagAddMethod: "Int64Var",
+		DefinedOn:     []string{"scale"},
+	},
+	{
+		Name:          "desired",
+		Usage:         "Desired size of nodegroup",
+		Value:         aws.Int64(0),
+		DefValue:      runner.NODEGROUP_SIZE_UNSET,
+		FlagAddMethod: "Int64Var",
+		DefinedOn:     []string{"scale"},
+	},
+	{
+		Name:          "max",
+		Usage:         "Maximum size of nodegroup",
+		Value:         aws.Int64(0),
+		DefValue:      runner.NODEGROUP_SIZE_UNSET,
+		FlagAddMethod: "Int64Var",
+		DefinedOn:     []string{"scale"},
+	},
+	{
+		Name:          "kubernetes-version",
+		Usage:         "Kubernetes version of nodegroup, default is the version of cluster",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"upgrade"},
+	},
+	{
+		Name:          "release-version",
+		Usage:         "AMI release version of nodegroup, default is the latest one",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"upgrade"},
+	},
+	{
+		Name:          "launch-template-version",
+		Usage:         "Version of launch template for nodegroup created with launch template",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"upgrade"},
+	},
+	{
+		Name:          "force",
+		Usage:         "Replace nodes even if pods cannot be drained because of pod disruption budget",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"upgrade"},
+	},
+	{
+		Name:          "timeout",
+		Usage:         "Time to watch update until it finishes",
+		Value:         new(time.Duration),
+		DefValue:      runner.EKS_UPDATE_TIMEOUT,
+		FlagAddMethod: "DurationVar",
+		DefinedOn:     []string{"scale", "upgrade", "addon update"},
+	},
+	{
+		Name:          "addon-version",
+		Usage:         "Version of add-on, default is the latest version compatible with the cluster",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"addon update"},
+	},
+	{
+		Name:          "resolve-conflicts",
+		Usage:         "How to resolve conflicts with fields changed in the cluster: NONE, OVERWRITE or PRESERVE",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"addon update"},
+	},
+	{
+		Name:          "configuration-values",
+		Usage:         "Configuration values of add-on in JSON or YAML, or @<file> to read them from file",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"addon update"},
+	},
+	{
+		Name:          "session",
+		Usage:         "Change context or namespace only in current shell with session kubeconfig",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"context", "namespace"},
+	},
+	{
+		Name:          "cluster",
+		Usage:         "Name of EKS cluster",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"token", "cluster", "nodegroup", "fargateprofile", "scale", "upgrade", "upgrade-check", "addon list", "addon describe", "addon update"},
+	},
+	{
+		Name:          "role-arn",
+		Usage:         "ARN of IAM role to assume before generating token",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"token"},
+	},
+	{
+		Name:          "assume",
+		Usage:         "Alias in assume configuration of kubenx, used instead of role ARN",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"token"},
+	},
+	{
+		Name:          "api-version",
+		Usage:         "API version of ExecCredential",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      utils.AUTH_API_VERSION,
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"token"},
+	},
+	{
+		Name:          "all-contexts",
+		Usage:         "Search in all contexts of kubeconfig",
+		Value:         aws.Bool(false),
+		DefValue:      false,
+		FlagAddMethod: "BoolVar",
+		DefinedOn:     []string{"image"},
+	},
+	{
+		Name:          "selector",
+		Shorthand:     "l",
+		Usage:         "Label selector to filter on, supports '=', '==', '!=', 'in', 'notin' and existence",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"search"},
+	},
+	{
+		Name:          "annotation",
+		Usage:         "Annotation to filter on, key or key=value (can be repeated)",
+		Value:         &[]string{},
+		DefValue:      []string{},
+		FlagAddMethod: "StringSliceVar",
+		DefinedOn:     []string{"search"},
+	},
+	{
+		Name:          "image",
+		Usage:         "Substring of container image to filter on",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"search"},
+	},
+	{
+		Name:          "name",
+		Usage:         "Regular expression of resource name to filter on",
+		Value:         aws.String(utils.NO_STRING),
+		DefValue:      "",
+		FlagAddMethod: "StringVar",
+		DefinedOn:     []string{"search"},
+	},
+	{
+		Name:          "kinds",
+		Usage:         "Resource types to search, including CRDs",
+		Value:         &[]string{},
+		DefValue:      utils.DEFAULT_SEARCH_KINDS,
+		FlagAddMethod: "StringSliceVar",
+		DefinedOn:     []string{"search"},
 	},
 }
 
@@ -88,36 +356,52 @@ func reflectValueOf(values []interface{}) []reflect.Value {
 //Add command flags
 func SetCommandFlags(cmd *cobra.Command) {
 	for _, child := range cmd.Commands() {
-		var flagsForCommand []*Flag
-		for i := range FlagRegistry {
-			fl := &FlagRegistry[i]
+		setFlagsOnCommand(child)
+	}
+}
 
-			if utils.IsStringInArray(child.Use, fl.DefinedOn) {
-				child.PersistentFlags().AddFlag(fl.flag())
-				flagsForCommand = append(flagsForCommand, fl)
-			}
+// Check whether the flag is defined on the command.
+// Command is referred with Use of its parent like "config update" if the Use is not unique
+func isFlagDefinedOn(cmd *cobra.Command, fl *Flag) bool {
+	if utils.IsStringInArray(cmd.Use, fl.DefinedOn) {
+		return true
+	}
+
+	return cmd.HasParent() && utils.IsStringInArray(cmd.Parent().Use+" "+cmd.Use, fl.DefinedOn)
+}
+
+// Add flags defined on the command itself
+func setFlagsOnCommand(cmd *cobra.Command) {
+	var flagsForCommand []*Flag
+	for i := range FlagRegistry {
+		fl := &FlagRegistry[i]
+
+		if isFlagDefinedOn(cmd, fl) {
+			cmd.Flags().AddFlag(fl.flag())
+			flagsForCommand = append(flagsForCommand, fl)
 		}
+	}
 
-		// Apply command-specific default values to flags.
-		child.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-			// Update default values.
-			for _, fl := range flagsForCommand {
-				viper.BindPFlag(fl.Name, cmd.PersistentFlags().Lookup(fl.Name))
-			}
+	// Apply command-specific default values to flags.
+	// cmd is the owner of flags and executed is the command actually running,
+	// which could be a descendant inheriting this hook.
+	cmd.PersistentPreRunE = func(executed *cobra.Command, args []string) error {
+		// Update default values.
+		for _, fl := range flagsForCommand {
+			viper.BindPFlag(fl.Name, executed.Flags().Lookup(fl.Name))
+		}
 
-			// Since PersistentPreRunE replaces the parent's PersistentPreRunE,
-			// make sure we call it, if it is set.
-			if parent := cmd.Parent(); parent != nil {
-				if preRun := parent.PersistentPreRunE; preRun != nil {
-					if err := preRun(cmd, args); err != nil {
-						return err
-					}
-				} else if preRun := parent.PersistentPreRun; preRun != nil {
-					preRun(cmd, args)
-				}
+		// Since PersistentPreRunE replaces the ancestors' PersistentPreRunE,
+		// make sure we call the nearest one, if it is set.
+		for parent := cmd.Parent(); parent != nil; parent = parent.Parent() {
+			if preRun := parent.PersistentPreRunE; preRun != nil {
+				return preRun(executed, args)
+			} else if preRun := parent.PersistentPreRun; preRun != nil {
+				preRun(executed, args)
+				return nil
 			}
-
-			return nil
 		}
+
+		return nil
 	}
 }
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+)
+
+var (
+	// Timeout of API request for each context not to wait unreachable clusters
+	CONTEXT_REQUEST_TIMEOUT = 15 * time.Second
+)
+
+//Search image command
+func NewCmdSearchImage() *cobra.Command {
+	return NewCmd("image").
+		WithDescription("Search pods running the container image").
+		WithLongDescription(`Search pods running the container image with digest.
+
+Pattern is matched as substring of image, or as glob if it has "*" or "?".
+Pods are searched in all namespaces unless namespace is given.
+
+  kubenx search image nginx:1.9
+  kubenx search image '*/library/nginx:1.1?' --all-contexts`).
+		SetAliases([]string{"images", "img"}).
+		RunWithArgsAndCmd(execSearchImage)
+}
+
+// Function for searching pods by image
+func execSearchImage(ctx context.Context, out io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+
+	matcher, err := runner.NewImageMatcher(args[0])
+	if err != nil {
+		return err
+	}
+
+	namespace := viper.GetString("namespace")
+
+	if !viper.GetBool("all-contexts") {
+		return runExecutor(ctx, func(executor Executor) error {
+			currentContext, err := runner.GetCurrentCluster()
+			if err != nil {
+				return err
+			}
+
+			usages, err := runner.FindImageUsages(ctx, executor.Client, currentContext, namespace, matcher)
+			if err != nil {
+				return err
+			}
+
+			if !runner.RenderImageUsageList(usages) {
+				color.Red.Fprintln(out, fmt.Sprintf("No pod runs the image %s", args[0]))
+			}
+			return nil
+		})
+	}
+
+	return runWithoutExecutor(ctx, func() error {
+		rawConfig, err := runner.GetCurrentConfig()
+		if err != nil {
+			return err
+		}
+
+		// Credential plugins could prompt MFA or SSO login, so contexts are searched with limited concurrency
+		var wg sync.WaitGroup
+		var lock sync.Mutex
+		semaphore := make(chan struct{}, runner.GetConcurrency())
+		usages := []runner.ImageUsage{}
+		for contextName := range rawConfig.Contexts {
+			wg.Add(1)
+			go func(contextName string) {
+				defer wg.Done()
+
+				semaphore <- struct{}{}
+				found, err := searchImageInContext(ctx, *rawConfig, contextName, namespace, matcher)
+				<-semaphore
+
+				lock.Lock()
+				defer lock.Unlock()
+				if err != nil {
+					color.Red.Fprintln(out, fmt.Sprintf("[%s] %s", contextName, err.Error()))
+					return
+				}
+				usages = append(usages, found...)
+			}(contextName)
+		}
+		wg.Wait()
+
+		if !runner.RenderImageUsageList(usages) {
+			color.Red.Fprintln(out, fmt.Sprintf("No pod runs the image %s in any context", args[0]))
+		}
+		return nil
+	})
+}
+
+// Search pods running the image in the context
+func searchImageInContext(ctx context.Context, rawConfig api.Config, contextName, namespace string, matcher *runner.ImageMatcher) ([]runner.ImageUsage, error) {
+	config, err := runner.GetConfigForContext(rawConfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = CONTEXT_REQUEST_TIMEOUT
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.FindImageUsages(ctx, clientset, contextName, namespace, matcher)
+}
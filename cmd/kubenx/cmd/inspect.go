@@ -13,18 +13,10 @@ func NewCmdInspect() *cobra.Command {
 		AddSearchGroups().
 		SetAliases([]string{"ins"}).
 		AddInspectGroups().
+		SetFlags().
 		RunWithArgsAndCmd(execInsepct)
 }
 
-// inspectCmd represents the inspect command
-var inspectCmd = &cobra.Command{
-	Use:     "inspect",
-	Short:   "A brief description of your command",
-	Long:    `Inspect resource in detail`,
-	Run:     func(cmd *cobra.Command, args []string) {},
-	Aliases: []string{"ins"},
-}
-
 // Function for search execution
 func execInsepct(_ context.Context, _ io.Writer, cmd *cobra.Command, args []string) error {
 	cmd.Help()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/history"
 	"github.com/GwonsooLee/kubenx/pkg/runner"
 	"github.com/GwonsooLee/kubenx/pkg/utils"
 	"github.com/spf13/cobra"
@@ -19,6 +20,7 @@ func NewCmdNamespace() *cobra.Command {
 	return NewCmd("namespace").
 		WithDescription("Change namespace").
 		SetAliases([]string{"ns"}).
+		SetOwnFlags().
 		RunWithArgs(execNamespace)
 }
 
@@ -28,7 +30,7 @@ func execNamespace(ctx context.Context, out io.Writer, args []string) error {
 		var namespaceList []string
 
 		//Get API configuration
-		configs, kubeconfig, err := runner.GetAPIConfig()
+		configs, configAccess, err := runner.GetAPIConfig()
 		if err != nil {
 			color.Red.Fprintln(out, err.Error())
 			return err
@@ -41,8 +43,8 @@ func execNamespace(ctx context.Context, out io.Writer, args []string) error {
 			return err
 		}
 
-		// use the current context in kubeconfig
-		config, err := clientcmd.BuildConfigFromFlags(utils.NO_STRING, *kubeconfig)
+		// use the current context in kubeconfig, which could be session kubeconfig
+		config, err := runner.GetClientConfig().ClientConfig()
 		if err != nil {
 			color.Red.Fprintln(out, err.Error())
 			return err
@@ -66,17 +68,29 @@ func execNamespace(ctx context.Context, out io.Writer, args []string) error {
 			namespaceList = append(namespaceList, obj.ObjectMeta.Name)
 		}
 
+		//getting current Context
+		currentContext := currentConfig.CurrentContext
+		currentNamespace := currentConfig.Contexts[currentConfig.CurrentContext].Namespace
+
+		// Context without namespace uses default namespace, so that `ns -` can switch back to it
+		if len(currentNamespace) == 0 {
+			currentNamespace = metav1.NamespaceDefault
+		}
+
+		// History is only for convenience, so switching works without it
+		namespaceHistory, err := history.Load()
+		if err != nil {
+			color.Yellow.Fprintln(out, fmt.Sprintf("cannot load history: %s", err.Error()))
+			namespaceHistory = &history.History{}
+		}
+
 		newNamespace := ""
 		if len(args) == 0 {
-			//getting current Context
-			currentContext := currentConfig.CurrentContext
-			currentNamespace := currentConfig.Contexts[currentConfig.CurrentContext].Namespace
-
 			// Get New Context
 			utils.Red("[ " + currentContext + " ] Current Namespace: " + currentNamespace)
 			prompt := &survey.Select{
 				Message: "Choose Context:",
-				Options: namespaceList,
+				Options: history.SortByRecent(namespaceList, namespaceHistory.RecentNamespaces(currentContext)),
 			}
 			survey.AskOne(prompt, &newNamespace)
 
@@ -88,6 +102,14 @@ func execNamespace(ctx context.Context, out io.Writer, args []string) error {
 		} else {
 			target := args[0]
 
+			if target == "-" {
+				previous, ok := namespaceHistory.PreviousNamespace(currentContext, currentNamespace)
+				if !ok {
+					return fmt.Errorf("no previous namespace of %s exists in history", currentContext)
+				}
+				target = previous
+			}
+
 			// Check whether the target exists in current context
 			containsTarget := false
 			for _, namespace := range namespaceList {
@@ -106,12 +128,26 @@ func execNamespace(ctx context.Context, out io.Writer, args []string) error {
 			newNamespace = target
 		}
 
-		//Change To New Namespace
-		currentConfig.Contexts[currentConfig.CurrentContext].Namespace = newNamespace
-		configAccess := clientcmd.NewDefaultClientConfig(*configs, &clientcmd.ConfigOverrides{}).ConfigAccess()
+		//Change To New Namespace only in the shell if session is used
+		isSession, err := switchSessionContext(out, currentConfig, currentContext, newNamespace)
+		if err != nil {
+			return err
+		}
+
+		if !isSession {
+			currentConfig.Contexts[currentContext].Namespace = newNamespace
 
-		clientcmd.ModifyConfig(configAccess, *currentConfig, false)
+			// Context given by --context should not become current context
+			currentConfig.CurrentContext = configs.CurrentContext
+
+			clientcmd.ModifyConfig(configAccess, *currentConfig, false)
+		}
 		color.Yellow.Fprintf(out, "Namespace is changed to %s", newNamespace)
+
+		namespaceHistory.AddNamespace(currentContext, currentNamespace, newNamespace)
+		if err := namespaceHistory.Save(); err != nil {
+			color.Yellow.Fprintln(out, fmt.Sprintf("cannot save history: %s", err.Error()))
+		}
 		return nil
 	})
 }
@@ -1,24 +1,257 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/color"
 	"github.com/GwonsooLee/kubenx/pkg/runner"
-	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/spf13/cobra"
-	"os"
+	"github.com/spf13/viper"
+	"io"
+	"time"
 )
 
-// ngCmd represents the ng command
-var ngCmd = &cobra.Command{
-	Use:   "nodegroup",
-	Short: "Command about node group",
-	Long:  `Command about node group`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) > 0 {
-			utils.Red("Too many Arguments")
-			os.Exit(1)
+// Create Command for managing nodegroup
+func NewCmdNodegroup() *cobra.Command {
+	return NewCmd("nodegroup").
+		WithDescription("EKS managed nodegroup related command").
+		SetAliases([]string{"ng"}).
+		AddCommand(NewCmdNodegroupScale()).
+		AddCommand(NewCmdNodegroupUpgrade()).
+		SetFlags().
+		RunWithArgsAndCmd(execNodegroup)
+}
+
+func execNodegroup(_ context.Context, _ io.Writer, cmd *cobra.Command, args []string) error {
+	cmd.Help()
+	return nil
+}
+
+// Create Command for get nodegroup
+func NewCmdGetNodegroup() *cobra.Command {
+	return NewCmd("nodegroup").
+		WithDescription("Get EKS managed nodegroup list").
+		SetAliases([]string{"nodegroups", "ng"}).
+		RunWithNoArgs(execGetNodegroup)
+}
+
+// Function for get nodegroup command
+func execGetNodegroup(ctx context.Context, out io.Writer) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		cluster := executor.EKSCluster.Name
+
+		nodegroups, err := runner.GetAllNodegroups(executor.EKS, cluster)
+		if err != nil {
+			return err
+		}
+
+		if !runner.RenderNodegroupListInfo(nodegroups) {
+			color.Red.Fprintln(out, fmt.Sprintf("No nodegroup exists in %s", cluster))
+		}
+
+		return nil
+	})
+}
+
+// Create Command for inspect nodegroup
+func NewCmdInspectNodegroup() *cobra.Command {
+	return NewCmd("nodegroup").
+		WithDescription("Inspect EKS managed nodegroup in detail").
+		SetAliases([]string{"nodegroups", "ng"}).
+		RunWithArgs(execInspectNodegroup)
+}
+
+// Function for inspect nodegroup command
+func execInspectNodegroup(ctx context.Context, out io.Writer, args []string) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		cluster := executor.EKSCluster.Name
+
+		// Get target nodegroup
+		target, err := runner.GetTargetNodegroup(executor.EKS, cluster, args)
+		if err != nil {
+			return err
+		}
+
+		nodegroup, err := aws.GetNodegroupInfo(executor.EKS, cluster, target)
+		if err != nil {
+			return err
 		}
 
-		runner.GetDetailInfoOfNodegroup()
-	},
-	Aliases: []string{"ng"},
+		runner.RenderNodegroupDetail(nodegroup)
+		fmt.Println()
+
+		// Health of instances in autoscaling groups
+		groups, err := runner.GetNodegroupAutoscalingGroups(executor.AutoScaling, nodegroup)
+		if err != nil {
+			return err
+		}
+
+		if !runner.RenderAutoscalingInstances(groups) {
+			color.Red.Fprintln(out, "No instance exists in autoscaling groups")
+		}
+
+		return nil
+	})
+}
+
+// Create Command for scaling nodegroup
+func NewCmdNodegroupScale() *cobra.Command {
+	return NewCmd("scale").
+		WithDescription("Change scaling configuration of nodegroup").
+		MarkAsMutating().
+		RunWithArgs(execNodegroupScale)
+}
+
+// Function for scale nodegroup command
+func execNodegroupScale(ctx context.Context, out io.Writer, args []string) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		// Nodes of the nodegroup are watched with kubernetes client of the cluster
+		if err := executor.CheckEKSClusterContext(); err != nil {
+			return err
+		}
+		cluster := executor.EKSCluster.Name
+
+		target, err := runner.GetTargetNodegroup(executor.EKS, cluster, args)
+		if err != nil {
+			return err
+		}
+
+		nodegroup, err := aws.GetNodegroupInfo(executor.EKS, cluster, target)
+		if err != nil {
+			return err
+		}
+
+		scaling, err := runner.GetNodegroupScalingConfig(nodegroup.ScalingConfig, viper.GetInt64("min"), viper.GetInt64("desired"), viper.GetInt64("max"))
+		if err != nil {
+			return err
+		}
+
+		color.Yellow.Fprintln(out, fmt.Sprintf("Scale %s from %s to %s (min/desired/max)", target, runner.GetNodegroupScalingLabel(nodegroup.ScalingConfig), runner.GetNodegroupScalingLabel(scaling)))
+
+		update, err := aws.UpdateNodegroupScaling(executor.EKS, cluster, target, scaling)
+		if err != nil {
+			return err
+		}
+
+		return waitForNodegroupUpdate(ctx, out, executor, target, update)
+	})
+}
+
+// Create Command for upgrading nodegroup
+func NewCmdNodegroupUpgrade() *cobra.Command {
+	return NewCmd("upgrade").
+		WithDescription("Upgrade kubernetes version or AMI of nodegroup with rolling replacement of nodes").
+		MarkAsMutating().
+		RunWithArgs(execNodegroupUpgrade)
+}
+
+// Function for upgrade nodegroup command
+func execNodegroupUpgrade(ctx context.Context, out io.Writer, args []string) error {
+	return runExecutorWithAWS(ctx, func(executor Executor) error {
+		// Nodes of the nodegroup are watched with kubernetes client of the cluster
+		if err := executor.CheckEKSClusterContext(); err != nil {
+			return err
+		}
+		cluster := executor.EKSCluster.Name
+
+		target, err := runner.GetTargetNodegroup(executor.EKS, cluster, args)
+		if err != nil {
+			return err
+		}
+
+		nodegroup, err := aws.GetNodegroupInfo(executor.EKS, cluster, target)
+		if err != nil {
+			return err
+		}
+
+		// Launch template version is only available for nodegroup created with launch template
+		var launchTemplate *eks.LaunchTemplateSpecification
+		if launchTemplateVersion := viper.GetString("launch-template-version"); len(launchTemplateVersion) > 0 {
+			if nodegroup.LaunchTemplate == nil {
+				return fmt.Errorf("%s is not created with launch template", target)
+			}
+			launchTemplate = &eks.LaunchTemplateSpecification{
+				Id:      nodegroup.LaunchTemplate.Id,
+				Version: &launchTemplateVersion,
+			}
+		}
+
+		version := viper.GetString("kubernetes-version")
+		releaseVersion := viper.GetString("release-version")
+
+		color.Yellow.Fprintln(out, fmt.Sprintf("Upgrade %s from %s to %s(%s)", target, runner.GetNodegroupVersionLabel(nodegroup), getVersionLabel(version, "cluster version"), getVersionLabel(releaseVersion, "latest AMI")))
+		confirmed, err := confirmUpdate(fmt.Sprintf("All nodes of %s will be replaced. Continue?", target))
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			color.Red.Fprintln(out, "Upgrade has been canceled")
+			return nil
+		}
+
+		update, err := aws.UpdateNodegroupVersion(executor.EKS, cluster, target, version, releaseVersion, launchTemplate, viper.GetBool("force"))
+		if err != nil {
+			return err
+		}
+
+		return waitForNodegroupUpdate(ctx, out, executor, target, update)
+	})
+}
+
+// Get version shown in messages
+func getVersionLabel(version, defaultLabel string) string {
+	if len(version) == 0 {
+		return defaultLabel
+	}
+
+	return version
+}
+
+// Watch update of nodegroup with nodes joining and leaving until it finishes
+func waitForNodegroupUpdate(ctx context.Context, out io.Writer, executor Executor, nodegroup string, update *eks.Update) error {
+	cluster := executor.EKSCluster.Name
+
+	nodes, err := runner.GetNodegroupNodeStatus(ctx, executor.Client, nodegroup)
+	if err != nil {
+		return err
+	}
+	color.Blue.Fprintln(out, fmt.Sprintf("%d nodes are in %s", len(nodes), nodegroup))
+
+	describe := func(updateID string) (*eks.Update, error) {
+		// Nodes could not be listed for a while, which should not stop watching
+		current, err := runner.GetNodegroupNodeStatus(ctx, executor.Client, nodegroup)
+		if err != nil {
+			color.Yellow.Fprintln(out, fmt.Sprintf("cannot list nodes: %s", err.Error()))
+		} else {
+			printNodeChanges(out, runner.DiffNodeStatus(nodes, current))
+			nodes = current
+		}
+
+		return aws.GetNodegroupUpdateInfo(executor.EKS, cluster, nodegroup, updateID)
+	}
+
+	if err := waitForEKSUpdate(ctx, out, update, describe); err != nil {
+		return err
+	}
+
+	color.Green.Fprintln(out, fmt.Sprintf("%s has been updated with %d nodes", nodegroup, len(nodes)))
+	return nil
+}
+
+// Print nodes joining, leaving or changing status
+func printNodeChanges(out io.Writer, changes []runner.NodeChange) {
+	for _, change := range changes {
+		line := fmt.Sprintf("%s node %s %s (%s)", time.Now().Format("15:04:05"), change.Node, change.Action, change.Status)
+		switch change.Action {
+		case runner.NODE_CHANGE_JOINED:
+			color.Green.Fprintln(out, line)
+		case runner.NODE_CHANGE_LEFT:
+			color.Red.Fprintln(out, line)
+		default:
+			color.Yellow.Fprintln(out, line)
+		}
+	}
 }
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	// Objects created by kubernetes or tools which are never referenced by workloads
+	IGNORED_UNUSED_CONFIGMAPS   = []string{"kube-root-ca.crt"}
+	IGNORED_UNUSED_SECRET_TYPES = []corev1.SecretType{corev1.SecretTypeServiceAccountToken, "helm.sh/release.v1"}
+)
+
+//Search references command
+func NewCmdSearchRefs() *cobra.Command {
+	return NewCmd("refs").
+		WithDescription("Search workloads referencing configmap or secret").
+		WithLongDescription(`Search which workloads reference a configmap or secret.
+
+Pod templates of deployments, statefulsets, daemonsets and cronjobs are scanned
+for env, envFrom, volumes and projected volumes. Secrets are also matched with
+imagePullSecrets, service accounts and ingress TLS.
+
+If only the kind is given, every configmap or secret is listed with the number
+of references and the ones referenced by nothing are marked as UNUSED.
+
+  kubenx search refs configmap/nginx-conf
+  kubenx search refs secret/registry-cred -A
+  kubenx search refs secret -n default`).
+		SetAliases([]string{"ref", "references"}).
+		RunWithArgsAndCmd(execSearchRefs)
+}
+
+// Function for searching references of configmap or secret
+func execSearchRefs(ctx context.Context, out io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+
+	parts := strings.SplitN(args[0], "/", 2)
+	kind, err := normalizeRefKind(parts[0])
+	if err != nil {
+		return err
+	}
+
+	return runExecutor(ctx, func(executor Executor) error {
+		mapper, err := runner.GetRESTMapper(executor.Config)
+		if err != nil {
+			return err
+		}
+
+		references, err := runner.FindAllConfigReferences(ctx, executor.Client, executor.Dynamic, mapper, executor.Namespace, kind)
+		if err != nil {
+			return err
+		}
+
+		// Show references of the specific object
+		if len(parts) == 2 {
+			matched := []runner.ConfigReference{}
+			for _, reference := range references {
+				if reference.Target == parts[1] {
+					matched = append(matched, reference)
+				}
+			}
+
+			if !runner.RenderConfigReferenceList(matched) {
+				color.Red.Fprintln(out, fmt.Sprintf("No workload references %s/%s", kind, parts[1]))
+			}
+			return nil
+		}
+
+		objects, err := getRefTargets(ctx, executor, kind)
+		if err != nil {
+			return err
+		}
+
+		if !runner.RenderConfigUsageList(objects, references) {
+			color.Red.Fprintln(out, fmt.Sprintf("No %s exists in the namespace", kind))
+		}
+
+		return nil
+	})
+}
+
+// Convert resource name of argument to kind of reference
+func normalizeRefKind(kind string) (string, error) {
+	switch strings.ToLower(kind) {
+	case "configmap", "configmaps", "cm":
+		return runner.REF_KIND_CONFIGMAP, nil
+	case "secret", "secrets":
+		return runner.REF_KIND_SECRET, nil
+	}
+
+	return "", fmt.Errorf("only configmap or secret is supported: %s", kind)
+}
+
+// Get configmaps or secrets which could be referenced by workloads
+func getRefTargets(ctx context.Context, executor Executor, kind string) ([]metav1.ObjectMeta, error) {
+	ret := []metav1.ObjectMeta{}
+
+	if kind == runner.REF_KIND_CONFIGMAP {
+		configmaps, err := executor.Client.CoreV1().ConfigMaps(executor.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, configmap := range configmaps.Items {
+			if utils.IsStringInArray(configmap.Name, IGNORED_UNUSED_CONFIGMAPS) {
+				continue
+			}
+			ret = append(ret, configmap.ObjectMeta)
+		}
+
+		return ret, nil
+	}
+
+	secrets, err := executor.Client.CoreV1().Secrets(executor.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, secret := range secrets.Items {
+		if isIgnoredSecretType(secret.Type) {
+			continue
+		}
+		ret = append(ret, secret.ObjectMeta)
+	}
+
+	return ret, nil
+}
+
+// Check whether the secret type is never referenced by workloads
+func isIgnoredSecretType(secretType corev1.SecretType) bool {
+	for _, ignored := range IGNORED_UNUSED_SECRET_TYPES {
+		if secretType == ignored {
+			return true
+		}
+	}
+	return false
+}
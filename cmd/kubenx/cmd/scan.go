@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+)
+
+// Create Command for scanning problems in the cluster
+func NewCmdScan() *cobra.Command {
+	return NewCmd("scan").
+		WithDescription("Scan problems of resources in the cluster").
+		AddCommand(NewCmdScanDeprecatedAPIs()).
+		SetFlags().
+		RunWithArgsAndCmd(execScan)
+}
+
+func execScan(_ context.Context, _ io.Writer, cmd *cobra.Command, args []string) error {
+	cmd.Help()
+	return nil
+}
+
+// Create Command for scanning deprecated APIs
+func NewCmdScanDeprecatedAPIs() *cobra.Command {
+	return NewCmd("deprecated-apis").
+		WithDescription("Find objects using APIs deprecated or removed in the target version").
+		WithLongDescription(`Find objects using APIs deprecated or removed in the target kubernetes version.
+
+Live objects are read with the version served by the cluster, so apiVersion used by
+clients is found from last-applied-configuration annotation and managed fields.
+Manifests of deployed helm releases are also scanned, because helm cannot upgrade
+a release whose manifest has API removed in the cluster.
+Target version is the next minor version of the cluster by default.`).
+		SetAliases([]string{"deprecated-api", "deprecations"}).
+		RunWithNoArgs(execScanDeprecatedAPIs)
+}
+
+// Function for scan deprecated-apis command
+func execScanDeprecatedAPIs(ctx context.Context, out io.Writer) error {
+	output := viper.GetString("output")
+	if output != OUTPUT_TABLE && output != OUTPUT_JSON {
+		return fmt.Errorf("--output should be one of %s, %s", OUTPUT_TABLE, OUTPUT_JSON)
+	}
+
+	return runExecutor(ctx, func(executor Executor) error {
+		serverVersion, err := executor.Client.Discovery().ServerVersion()
+		if err != nil {
+			return err
+		}
+
+		result := runner.DeprecatedAPIScanResult{
+			ServerVersion: serverVersion.GitVersion,
+			TargetVersion: viper.GetString("target-version"),
+			Errors:        []string{},
+		}
+		if len(result.TargetVersion) == 0 {
+			result.TargetVersion = runner.GetNextKubernetesVersion(serverVersion.GitVersion)
+		}
+
+		mapper, err := runner.GetRESTMapper(executor.Config)
+		if err != nil {
+			return err
+		}
+
+		usages, errs := runner.ScanDeprecatedAPIUsages(ctx, executor.Client, executor.Dynamic, mapper, result.TargetVersion)
+		result.Usages = usages
+		for _, scanErr := range errs {
+			result.Errors = append(result.Errors, scanErr.Error())
+		}
+
+		if output == OUTPUT_JSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			color.Blue.Fprintln(out, fmt.Sprintf("Scanning APIs deprecated in %s of cluster %s", result.TargetVersion, result.ServerVersion))
+			for _, scanErr := range result.Errors {
+				color.Red.Fprintln(out, scanErr)
+			}
+
+			// Objects which could not be listed might use deprecated APIs
+			if !runner.RenderDeprecatedAPIUsageList(usages) && len(result.Errors) == 0 {
+				color.Green.Fprintln(out, fmt.Sprintf("No object uses APIs deprecated in %s", result.TargetVersion))
+				return nil
+			}
+		}
+
+		if !viper.GetBool("exit-code") {
+			return nil
+		}
+
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("scan is incomplete with %d errors", len(result.Errors))
+		}
+
+		if len(usages) > 0 {
+			return fmt.Errorf("%d objects use APIs deprecated in %s", len(usages), result.TargetVersion)
+		}
+
+		return nil
+	})
+}
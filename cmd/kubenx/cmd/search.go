@@ -7,16 +7,29 @@ import (
 	"github.com/GwonsooLee/kubenx/pkg/runner"
 	"github.com/GwonsooLee/kubenx/pkg/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"io"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"os"
+	"regexp"
 )
 
 //Create Command for get pod
 func NewCmdSearch() *cobra.Command {
 	return NewCmd("search").
 		WithDescription("Search resources").
+		WithLongDescription(`Search resources across resource types in all namespaces.
+
+Conditions are combined with AND. Resource types could be any resource name,
+short name or CRD like "deploy,svc,certificates.cert-manager.io".
+
+  kubenx search -l app=nginx,env!=prod
+  kubenx search --image nginx:1.9 --kinds deploy,sts,ds
+  kubenx search --annotation eks.amazonaws.com/role-arn --kinds sa
+  kubenx search --name '^web-' -n default`).
 		AddSearchGroups().
+		SetFlags().
+		SetOwnFlags().
 		RunWithArgsAndCmd(execSearch)
 }
 
@@ -29,9 +42,58 @@ func NewCmdSearchLabel() *cobra.Command {
 }
 
 // Function for search execution
-func execSearch(_ context.Context, _ io.Writer, cmd *cobra.Command, args []string) error {
-	cmd.Help()
-	return nil
+func execSearch(ctx context.Context, out io.Writer, cmd *cobra.Command, args []string) error {
+	filter := runner.SearchFilter{
+		LabelSelector: viper.GetString("selector"),
+		Annotations:   viper.GetStringSlice("annotation"),
+		Image:         viper.GetString("image"),
+	}
+
+	if pattern := viper.GetString("name"); len(pattern) > 0 {
+		name, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		filter.Name = name
+	}
+
+	// Show help if no condition is given
+	if len(filter.LabelSelector) == 0 && len(filter.Annotations) == 0 && len(filter.Image) == 0 && filter.Name == nil {
+		cmd.Help()
+		return nil
+	}
+
+	return runExecutor(ctx, func(executor Executor) error {
+		mapper, err := runner.GetRESTMapper(executor.Config)
+		if err != nil {
+			return err
+		}
+
+		// Search in all namespaces unless namespace is given
+		namespace := viper.GetString("namespace")
+
+		results := []runner.SearchResult{}
+		for _, kind := range viper.GetStringSlice("kinds") {
+			mapping, err := runner.GetRESTMappingForResource(mapper, kind)
+			if err != nil {
+				color.Red.Fprintln(out, fmt.Sprintf("cannot find resource type %s: %s", kind, err.Error()))
+				continue
+			}
+
+			found, err := runner.SearchResources(ctx, executor.Dynamic, mapping, namespace, filter)
+			if err != nil {
+				color.Red.Fprintln(out, fmt.Sprintf("cannot search %s: %s", kind, err.Error()))
+				continue
+			}
+			results = append(results, found...)
+		}
+
+		if !runner.RenderSearchResultList(results) {
+			color.Red.Fprintln(out, "No resource matches the condition")
+		}
+
+		return nil
+	})
 }
 
 // Function for search via label
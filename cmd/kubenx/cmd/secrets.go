@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/GwonsooLee/kubenx/pkg/color"
 	"github.com/GwonsooLee/kubenx/pkg/runner"
 	"github.com/GwonsooLee/kubenx/pkg/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"io"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
 )
 
 //Create Command for get pod
@@ -33,3 +38,104 @@ func execGetSecret(ctx context.Context, out io.Writer) error {
 		return nil
 	})
 }
+
+//Create Command for secret
+func NewCmdSecret() *cobra.Command {
+	return NewCmd("secret").
+		WithDescription("Handle secrets in the namespace").
+		AddCommand(NewCmdSecretView()).
+		SetFlags().
+		RunWithArgsAndCmd(execSecret)
+}
+
+//Create Command for viewing decoded secret
+func NewCmdSecretView() *cobra.Command {
+	return NewCmd("view").
+		WithDescription("View decoded values of secret").
+		WithLongDescription(`View decoded values of secret.
+
+Certificates and docker config json are shown in readable format.
+Values are masked unless --reveal is given.
+If a single key is given with --reveal, only the raw value is printed.
+
+  kubenx secret view <name> [key]`).
+		SetAliases([]string{"decode"}).
+		RunWithArgs(execSecretView)
+}
+
+// Function for secret execution
+func execSecret(_ context.Context, _ io.Writer, cmd *cobra.Command, args []string) error {
+	cmd.Help()
+	return nil
+}
+
+// Function for viewing decoded secret
+func execSecretView(ctx context.Context, out io.Writer, args []string) error {
+	return runExecutor(ctx, func(executor Executor) error {
+		if len(args) > 2 {
+			return fmt.Errorf("too many arguments, usage: kubenx secret view <name> [key]")
+		}
+
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		} else {
+			secrets, err := runner.GetAllRawSecrets(ctx, executor.Client, executor.Namespace, utils.NO_STRING)
+			if err != nil {
+				return err
+			}
+
+			options := []string{}
+			for _, secret := range secrets {
+				options = append(options, secret.Name)
+			}
+
+			if len(options) == 0 {
+				color.Red.Fprintln(out, "No secret exists in the namespace")
+				return nil
+			}
+
+			prompt := &survey.Select{
+				Message: "Choose a secret:",
+				Options: options,
+			}
+			survey.AskOne(prompt, &name)
+
+			if name == "" {
+				return fmt.Errorf("Choice has been canceled")
+			}
+		}
+
+		secret, err := executor.Client.CoreV1().Secrets(executor.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		reveal := viper.GetBool("reveal")
+		keys := runner.GetSecretKeys(*secret)
+		if len(args) == 2 {
+			key := args[1]
+			value, ok := secret.Data[key]
+			if !ok {
+				return fmt.Errorf("key %s doesn't exist in secret %s", key, name)
+			}
+
+			// Print raw value only so that it could be redirected to file
+			if reveal {
+				_, err := os.Stdout.Write(value)
+				return err
+			}
+			keys = []string{key}
+		}
+
+		// Decoded values are written to stdout together with their headers
+		color.Blue.Fprintln(os.Stdout, fmt.Sprintf("[ %s/%s ] type: %s", secret.Namespace, secret.Name, secret.Type))
+		runner.RenderSecretData(os.Stdout, *secret, keys, reveal)
+
+		if !reveal {
+			color.Cyan.Fprintln(out, "Values are masked. Use --reveal to show them.")
+		}
+
+		return nil
+	})
+}
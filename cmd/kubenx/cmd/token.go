@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	SUPPORTED_AUTH_API_VERSIONS = []string{
+		"client.authentication.k8s.io/v1",
+		"client.authentication.k8s.io/v1beta1",
+		"client.authentication.k8s.io/v1alpha1",
+	}
+)
+
+//Create Command for token
+func NewCmdToken() *cobra.Command {
+	return NewCmd("token").
+		WithDescription("Print EKS token as ExecCredential for kubeconfig").
+		WithLongDescription(`Print EKS authentication token as ExecCredential.
+
+This command is used as credential plugin in kubeconfig written by
+"kubenx config update" or "kubenx config init". Token is cached under
+~/.kubenx/cache until it expires.
+
+  kubenx token --cluster eks-dev --role-arn arn:aws:iam::111111111111:role/admin`).
+		SetOwnFlags().
+		RunWithNoArgs(execToken)
+}
+
+// Function for printing EKS token
+func execToken(ctx context.Context, out io.Writer) error {
+	return runWithoutExecutor(ctx, func() error {
+		cluster := viper.GetString("cluster")
+		if len(cluster) == 0 {
+			return fmt.Errorf("--cluster is required")
+		}
+
+		apiVersion := viper.GetString("api-version")
+		if !utils.IsStringInArray(apiVersion, SUPPORTED_AUTH_API_VERSIONS) {
+			return fmt.Errorf("unsupported api version %s", apiVersion)
+		}
+
+		// Alias is used for AWS SSO permission set which has no role ARN
+		reference := viper.GetString("role-arn")
+		if len(reference) == 0 {
+			reference = viper.GetString("assume")
+		}
+
+		credential, err := aws.GetEKSExecCredential(cluster, reference, aws.GetAssumeSessionName(), viper.GetString("region"), apiVersion)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(os.Stdout).Encode(credential)
+	})
+}
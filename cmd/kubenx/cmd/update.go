@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/runner"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Ask confirmation of update unless --yes is set
+func confirmUpdate(message string) (bool, error) {
+	if viper.GetBool("yes") {
+		return true, nil
+	}
+
+	confirmed := false
+	prompt := &survey.Confirm{Message: message}
+	if err := survey.AskOne(prompt, &confirmed, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+		return false, err
+	}
+
+	return confirmed, nil
+}
+
+// Poll update of EKS resource until it finishes or --timeout is passed.
+// Cancelling the command stops only watching, not the update
+func waitForEKSUpdate(ctx context.Context, out io.Writer, update *eks.Update, describe func(string) (*eks.Update, error)) error {
+	updateID := *update.Id
+	status := *update.Status
+	color.Blue.Fprintln(out, fmt.Sprintf("Update %s is %s", updateID, status))
+
+	timeout := time.After(viper.GetDuration("timeout"))
+	ticker := time.NewTicker(runner.EKS_UPDATE_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("update %s is still %s after %s", updateID, status, viper.GetDuration("timeout"))
+		case <-ticker.C:
+		}
+
+		update, err := describe(updateID)
+		if err != nil {
+			return err
+		}
+
+		if *update.Status != status {
+			status = *update.Status
+			color.Blue.Fprintln(out, fmt.Sprintf("Update %s is %s", updateID, status))
+		}
+
+		switch status {
+		case eks.UpdateStatusSuccessful:
+			return nil
+		case eks.UpdateStatusFailed, eks.UpdateStatusCancelled:
+			return fmt.Errorf("update %s is %s: %s", updateID, strings.ToLower(status), strings.Join(runner.GetUpdateErrors(update), ", "))
+		}
+	}
+}
@@ -6,13 +6,14 @@ require (
 	github.com/AlecAivazis/survey/v2 v2.0.7
 	github.com/apaxa-go/eval v0.0.0-20171223182326-1d18b251d679
 	github.com/apaxa-go/helper v0.0.0-20180607175117-61d31b1c31c3 // indirect
-	github.com/aws/aws-sdk-go v1.29.29
+	github.com/aws/aws-sdk-go v1.44.332
 	github.com/evanphx/json-patch v4.2.0+incompatible
 	github.com/fatih/color v1.9.0
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
 	github.com/imdario/mergo v0.3.8 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mitchellh/mapstructure v1.3.0 // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/olekukonko/tablewriter v0.0.4
 	github.com/pelletier/go-toml v1.7.0 // indirect
 	github.com/sirupsen/logrus v1.4.2
@@ -21,9 +22,8 @@ require (
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.0
-	golang.org/x/crypto v0.0.0-20200220183623-bac4c82f6975
-	golang.org/x/sys v0.0.0-20200515095857-1151b9dac4a9 // indirect
-	gopkg.in/ini.v1 v1.56.0 // indirect
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
+	gopkg.in/ini.v1 v1.56.0
 	gopkg.in/yaml.v2 v2.3.0 // indirect
 	k8s.io/api v0.18.3
 	k8s.io/apimachinery v0.18.3
@@ -0,0 +1,186 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+var (
+	// Limit of role chain not to loop forever with wrong source_role
+	MAX_ROLE_CHAIN_DEPTH = 5
+
+	// Session token issued with MFA is reused for all roles with the same device
+	MFA_SESSION_DURATION = 12 * time.Hour
+
+	// Lock for prompting MFA code or SSO device authorization
+	promptLock sync.Mutex
+)
+
+// Configuration of role to assume
+// It could be written as role ARN string or object in kubenx configuration
+type AssumeRoleConfig struct {
+	RoleArn         string `json:"role_arn"`
+	MFASerial       string `json:"mfa_serial,omitempty"`
+	ExternalID      string `json:"external_id,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+	SourceProfile   string `json:"source_profile,omitempty"`
+	SourceRole      string `json:"source_role,omitempty"`
+
+	// AWS SSO permission set used as credentials, or as source of role_arn
+	SSOStartURL  string `json:"sso_start_url,omitempty"`
+	SSORegion    string `json:"sso_region,omitempty"`
+	SSOAccountID string `json:"sso_account_id,omitempty"`
+	SSORoleName  string `json:"sso_role_name,omitempty"`
+}
+
+// Unmarshal role ARN string or object
+func (c *AssumeRoleConfig) UnmarshalJSON(data []byte) error {
+	var roleArn string
+	if err := json.Unmarshal(data, &roleArn); err == nil {
+		*c = AssumeRoleConfig{RoleArn: roleArn}
+		return nil
+	}
+
+	type assumeRoleConfig AssumeRoleConfig
+	return json.Unmarshal(data, (*assumeRoleConfig)(c))
+}
+
+// Find assume configuration by alias or role ARN. Role without configuration is assumed with default options
+func FindAssumeRoleConfig(reference string) AssumeRoleConfig {
+	kubeEKSConfig, err := FindEKSAussmeInfo()
+	if err == nil {
+		if config, ok := kubeEKSConfig.Assume[reference]; ok {
+			return config
+		}
+
+		for _, config := range kubeEKSConfig.Assume {
+			if config.RoleArn == reference {
+				return config
+			}
+		}
+	}
+
+	return AssumeRoleConfig{RoleArn: reference}
+}
+
+// Get credential provider for the assume configuration
+func newAssumeProvider(region string, config AssumeRoleConfig, sessionName string, depth int) credentials.Provider {
+	if len(config.RoleArn) == 0 && config.IsSSO() {
+		return &CachedSSOProvider{Config: config}
+	}
+
+	return &CachedAssumeRoleProvider{
+		Region:      region,
+		Config:      config,
+		SessionName: sessionName,
+		depth:       depth,
+	}
+}
+
+// Get STS client with credentials of source profile, source role or MFA session
+func getSourceSTSClient(region string, config AssumeRoleConfig, sessionName string, depth int) (stsiface.STSAPI, error) {
+	if depth > MAX_ROLE_CHAIN_DEPTH {
+		return nil, fmt.Errorf("role chain of %s is too deep, please check source_role", config.RoleArn)
+	}
+
+	mySession, err := session.NewSessionWithOptions(session.Options{
+		Profile:           config.SourceProfile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{Region: aws.String(region)}
+	switch {
+	case len(config.SourceRole) > 0:
+		kubeEKSConfig, err := FindEKSAussmeInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		source, ok := kubeEKSConfig.Assume[config.SourceRole]
+		if !ok {
+			return nil, fmt.Errorf("source role %s of %s does not exist in %s", config.SourceRole, config.RoleArn, CONFIG_FILE_PATH)
+		}
+
+		// Source credentials are retrieved here, because MFA prompt of the role holds the prompt lock
+		sourceCredentials := credentials.NewCredentials(newAssumeProvider(region, source, sessionName, depth+1))
+		if _, err := sourceCredentials.Get(); err != nil {
+			return nil, err
+		}
+		awsConfig.Credentials = sourceCredentials
+	case config.IsSSO():
+		awsConfig.Credentials = credentials.NewCredentials(&CachedSSOProvider{Config: config})
+	case len(config.MFASerial) > 0:
+		awsConfig.Credentials = credentials.NewCredentials(&CachedSessionTokenProvider{
+			Client:        sts.New(mySession, &aws.Config{Region: aws.String(region)}),
+			MFASerial:     config.MFASerial,
+			SourceProfile: config.SourceProfile,
+		})
+	}
+
+	return sts.New(mySession, awsConfig), nil
+}
+
+// Credential provider issuing session token with MFA and on-disk cache
+type CachedSessionTokenProvider struct {
+	credentials.Expiry
+
+	Client        stsiface.STSAPI
+	MFASerial     string
+	SourceProfile string
+}
+
+// Retrieve session token from cache or with MFA code
+func (p *CachedSessionTokenProvider) Retrieve() (credentials.Value, error) {
+	// Prompt only once even if several roles need the token at the same time
+	promptLock.Lock()
+	defer promptLock.Unlock()
+
+	cachePath := getCredentialCachePath("mfa", p.MFASerial, p.SourceProfile)
+	creds, err := readCredentialCache(cachePath)
+	if err != nil {
+		code, err := promptMFACode(p.MFASerial)
+		if err != nil {
+			return credentials.Value{ProviderName: CACHED_ASSUME_ROLE_PROVIDER_NAME}, err
+		}
+
+		result, err := p.Client.GetSessionToken(&sts.GetSessionTokenInput{
+			SerialNumber:    aws.String(p.MFASerial),
+			TokenCode:       aws.String(code),
+			DurationSeconds: aws.Int64(int64(MFA_SESSION_DURATION / time.Second)),
+		})
+		if err != nil {
+			return credentials.Value{ProviderName: CACHED_ASSUME_ROLE_PROVIDER_NAME}, err
+		}
+
+		creds = result.Credentials
+		_ = writeCredentialCache(cachePath, creds)
+	}
+
+	p.SetExpiration(*creds.Expiration, CREDENTIAL_EXPIRY_WINDOW)
+
+	return getCredentialValue(creds), nil
+}
+
+// Ask MFA code to user. Prompt is written to stderr not to break output of commands like token
+func promptMFACode(serial string) (string, error) {
+	var code string
+	prompt := &survey.Input{Message: fmt.Sprintf("MFA code for %s:", serial)}
+	if err := survey.AskOne(prompt, &code, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr), survey.WithValidator(survey.Required)); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
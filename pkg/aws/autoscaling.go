@@ -0,0 +1,48 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/spf13/viper"
+)
+
+// Get Autoscaling Session
+func GetAutoscalingSession(role *string) *autoscaling.AutoScaling {
+	return GetAutoscalingSessionInRegion(role, viper.GetString("region"))
+}
+
+// Get Autoscaling Session of the region
+func GetAutoscalingSessionInRegion(role *string, awsRegion string) *autoscaling.AutoScaling {
+	mySession := session.Must(session.NewSession())
+
+	var creds *credentials.Credentials
+	if role != nil {
+		creds = NewCachedAssumeRoleCredentials(awsRegion, *role)
+	}
+
+	if creds == nil {
+		return autoscaling.New(mySession, &aws.Config{Region: aws.String(awsRegion)})
+	}
+	return autoscaling.New(mySession, &aws.Config{Region: aws.String(awsRegion), Credentials: creds})
+}
+
+// Describe autoscaling groups with names
+func GetAutoscalingGroupsInfo(svc *autoscaling.AutoScaling, names []*string) ([]*autoscaling.Group, error) {
+	ret := []*autoscaling.Group{}
+	if len(names) == 0 {
+		return ret, nil
+	}
+
+	inputParam := &autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: names}
+	err := svc.DescribeAutoScalingGroupsPages(inputParam, func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+		ret = append(ret, page.AutoScalingGroups...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
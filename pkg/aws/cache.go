@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	CACHED_ASSUME_ROLE_PROVIDER_NAME = "KubenxCachedAssumeRoleProvider"
+	DEFAULT_ASSUME_SESSION_NAME      = "kubenx"
+	CREDENTIAL_CACHE_DIR             = filepath.Join(utils.HomeDir(), utils.KUBENX_HOMEDIR, "cache", "credentials")
+
+	// Cached credentials are not used if they expire within the window
+	CREDENTIAL_EXPIRY_WINDOW = 5 * time.Minute
+)
+
+// Credentials stored in cache file
+type CachedCredential struct {
+	AccessKeyId     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+// Credential provider assuming role with on-disk cache
+type CachedAssumeRoleProvider struct {
+	credentials.Expiry
+
+	Region      string
+	Config      AssumeRoleConfig
+	SessionName string
+
+	depth int
+}
+
+// Create credentials of the alias or role which are shared across kubenx invocations
+func NewCachedAssumeRoleCredentials(region, reference string) *credentials.Credentials {
+	return credentials.NewCredentials(newAssumeProvider(region, FindAssumeRoleConfig(reference), GetAssumeSessionName(), 0))
+}
+
+// Retrieve credentials from cache or assume role
+func (p *CachedAssumeRoleProvider) Retrieve() (credentials.Value, error) {
+	creds, err := p.assumeRole()
+	if err != nil {
+		return credentials.Value{ProviderName: CACHED_ASSUME_ROLE_PROVIDER_NAME}, err
+	}
+
+	p.SetExpiration(*creds.Expiration, CREDENTIAL_EXPIRY_WINDOW)
+
+	return getCredentialValue(creds), nil
+}
+
+// Assume role only if credentials in cache are not valid
+func (p *CachedAssumeRoleProvider) assumeRole() (*sts.Credentials, error) {
+	cachePath := getCredentialCachePath(p.Config.RoleArn, p.SessionName)
+	if creds, err := readCredentialCache(cachePath); err == nil {
+		return creds, nil
+	}
+
+	client, err := getSourceSTSClient(p.Region, p.Config, p.SessionName, p.depth)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(p.Config.RoleArn),
+		RoleSessionName: aws.String(p.SessionName),
+	}
+
+	if len(p.Config.ExternalID) > 0 {
+		input.ExternalId = aws.String(p.Config.ExternalID)
+	}
+
+	if p.Config.DurationSeconds > 0 {
+		input.DurationSeconds = aws.Int64(p.Config.DurationSeconds)
+	}
+
+	// Session token could not be issued with role credentials, so MFA is passed to assume role directly
+	if len(p.Config.SourceRole) > 0 && len(p.Config.MFASerial) > 0 {
+		// Prompt only once even if several clusters need the role at the same time
+		promptLock.Lock()
+		defer promptLock.Unlock()
+
+		// Credentials could be cached by another prompt while waiting for the lock
+		if creds, err := readCredentialCache(cachePath); err == nil {
+			return creds, nil
+		}
+
+		code, err := promptMFACode(p.Config.MFASerial)
+		if err != nil {
+			return nil, err
+		}
+		input.SerialNumber = aws.String(p.Config.MFASerial)
+		input.TokenCode = aws.String(code)
+	}
+
+	result, err := client.AssumeRole(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failure of caching should not block assuming role
+	_ = writeCredentialCache(cachePath, result.Credentials)
+
+	return result.Credentials, nil
+}
+
+// Get session name for assume role from kubenx configuration
+func GetAssumeSessionName() string {
+	kubeEKSConfig, err := FindEKSAussmeInfo()
+	if err != nil || len(kubeEKSConfig.SessionName) == 0 {
+		return DEFAULT_ASSUME_SESSION_NAME
+	}
+
+	return kubeEKSConfig.SessionName
+}
+
+// Convert STS credentials to credential value
+func getCredentialValue(creds *sts.Credentials) credentials.Value {
+	return credentials.Value{
+		AccessKeyID:     *creds.AccessKeyId,
+		SecretAccessKey: *creds.SecretAccessKey,
+		SessionToken:    *creds.SessionToken,
+		ProviderName:    CACHED_ASSUME_ROLE_PROVIDER_NAME,
+	}
+}
+
+// Get cache file path of credentials
+func getCredentialCachePath(keys ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(keys, "|")))
+	return filepath.Join(CREDENTIAL_CACHE_DIR, hex.EncodeToString(sum[:])+".json")
+}
+
+// Read credentials from cache if they are still valid
+func readCredentialCache(path string) (*sts.Credentials, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := CachedCredential{}
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		return nil, err
+	}
+
+	if time.Now().Add(CREDENTIAL_EXPIRY_WINDOW).After(cached.Expiration) {
+		return nil, os.ErrNotExist
+	}
+
+	return &sts.Credentials{
+		AccessKeyId:     aws.String(cached.AccessKeyId),
+		SecretAccessKey: aws.String(cached.SecretAccessKey),
+		SessionToken:    aws.String(cached.SessionToken),
+		Expiration:      aws.Time(cached.Expiration),
+	}, nil
+}
+
+// Write credentials to cache only readable by owner
+func writeCredentialCache(path string, creds *sts.Credentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(CachedCredential{
+		AccessKeyId:     aws.StringValue(creds.AccessKeyId),
+		SecretAccessKey: aws.StringValue(creds.SecretAccessKey),
+		SessionToken:    aws.StringValue(creds.SessionToken),
+		Expiration:      aws.TimeValue(creds.Expiration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomically(path, raw)
+}
@@ -0,0 +1,44 @@
+package aws
+
+// Metadata of kubeconfig context written in kubenx configuration
+type ContextMetadata struct {
+	Aliases   []string `json:"aliases,omitempty"`
+	Color     string   `json:"color,omitempty"`
+	Env       string   `json:"env,omitempty"`
+	Protected bool     `json:"protected,omitempty"`
+
+	// Namespace of the context written to kubeconfig
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Find metadata of the context. Context without metadata gets empty one
+func FindContextMetadata(contextName string) ContextMetadata {
+	kubenxConfig, err := FindEKSAussmeInfo()
+	if err != nil {
+		return ContextMetadata{}
+	}
+
+	return kubenxConfig.Contexts[contextName]
+}
+
+// Get context name of the alias. Name is returned as it is if it is not an alias
+func ResolveContextAlias(name string) string {
+	kubenxConfig, err := FindEKSAussmeInfo()
+	if err != nil {
+		return name
+	}
+
+	if _, ok := kubenxConfig.Contexts[name]; ok {
+		return name
+	}
+
+	for contextName, metadata := range kubenxConfig.Contexts {
+		for _, alias := range metadata.Aliases {
+			if alias == name {
+				return contextName
+			}
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"gopkg.in/ini.v1"
+
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+// Get environment variables for credentials
+func GetCredentialEnvs(creds *sts.Credentials) []string {
+	return []string{
+		fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", *creds.AccessKeyId),
+		fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", *creds.SecretAccessKey),
+		fmt.Sprintf("AWS_SESSION_TOKEN=%s", *creds.SessionToken),
+	}
+}
+
+// Get export lines for credentials which could be evaluated by shell
+func GetCredentialExports(creds *sts.Credentials) string {
+	var builder strings.Builder
+	for _, env := range GetCredentialEnvs(creds) {
+		builder.WriteString(fmt.Sprintf("export %s\n", env))
+	}
+
+	return builder.String()
+}
+
+// Get path of shared credentials file
+func GetSharedCredentialsFilePath() string {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); len(path) > 0 {
+		return path
+	}
+
+	return filepath.Join(utils.HomeDir(), ".aws", "credentials")
+}
+
+// Write credentials as named profile into shared credentials file
+func WriteCredentialsProfile(profile string, creds *sts.Credentials) error {
+	path := GetSharedCredentialsFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Create file before writing credentials not to be readable by others
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+			return err
+		}
+	}
+
+	file, err := ini.LooseLoad(path)
+	if err != nil {
+		return err
+	}
+
+	section := file.Section(profile)
+	section.Key("aws_access_key_id").SetValue(*creds.AccessKeyId)
+	section.Key("aws_secret_access_key").SetValue(*creds.SecretAccessKey)
+	section.Key("aws_session_token").SetValue(*creds.SessionToken)
+
+	if err := file.SaveTo(path); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, 0600)
+}
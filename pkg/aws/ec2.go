@@ -3,20 +3,37 @@ package aws
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/spf13/viper"
 )
 
+var (
+	// Value of --regions for every enabled region
+	ALL_ENABLED_REGIONS = "all-enabled"
+
+	// Tags for discovery of VPC and subnets by the cluster
+	CLUSTER_TAG_PREFIX = "kubernetes.io/cluster/"
+	CLUSTER_TAG_VALUE  = "shared"
+	PUBLIC_ELB_TAG     = "kubernetes.io/role/elb"
+	INTERNAL_ELB_TAG   = "kubernetes.io/role/internal-elb"
+
+	SUBNET_TYPE_PUBLIC  = "public"
+	SUBNET_TYPE_PRIVATE = "private"
+)
+
 // Get EC2 Session
 func GetEC2Session(role *string) *ec2.EC2 {
-	awsRegion := viper.GetString("region")
+	return GetEC2SessionInRegion(role, viper.GetString("region"))
+}
+
+// Get EC2 Session of the region
+func GetEC2SessionInRegion(role *string, awsRegion string) *ec2.EC2 {
 	mySession := session.Must(session.NewSession())
 
 	var creds *credentials.Credentials
 	if role != nil {
-		creds = stscreds.NewCredentials(mySession, *role)
+		creds = NewCachedAssumeRoleCredentials(awsRegion, *role)
 	}
 
 	if creds == nil {
@@ -25,6 +42,38 @@ func GetEC2Session(role *string) *ec2.EC2 {
 	return ec2.New(mySession, &aws.Config{Region: aws.String(awsRegion), Credentials: creds})
 }
 
+// Get regions to discover clusters in from --regions flag.
+// all-enabled means every region enabled in the account of the role
+func GetTargetRegions(role *string) ([]string, error) {
+	regions := viper.GetStringSlice("regions")
+	if len(regions) == 0 {
+		return []string{viper.GetString("region")}, nil
+	}
+
+	for _, region := range regions {
+		if region == ALL_ENABLED_REGIONS {
+			return GetEnabledRegions(GetEC2Session(role))
+		}
+	}
+
+	return regions, nil
+}
+
+// Get all regions enabled in the account
+func GetEnabledRegions(svc *ec2.EC2) ([]string, error) {
+	result, err := svc.DescribeRegions(&ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []string{}
+	for _, region := range result.Regions {
+		ret = append(ret, aws.StringValue(region.RegionName))
+	}
+
+	return ret, nil
+}
+
 // Describe Single VPC Information
 func GetVPCInfo(svc *ec2.EC2, vpcId *string) (*ec2.DescribeVpcsOutput, error) {
 	var vpcIds []*string
@@ -76,12 +125,7 @@ func UpdateVPCTagForCluster(svc *ec2.EC2, vpcId *string, cluster string) error {
 		Resources: []*string{
 			aws.String(*vpcId),
 		},
-		Tags: []*ec2.Tag{
-			{
-				Key:   aws.String("kubernetes.io/cluster/" + cluster),
-				Value: aws.String("shared"),
-			},
-		},
+		Tags: GetVPCTagsForCluster(cluster),
 	}
 	_, err := svc.CreateTags(inputParam)
 	if err != nil {
@@ -91,35 +135,40 @@ func UpdateVPCTagForCluster(svc *ec2.EC2, vpcId *string, cluster string) error {
 	return nil
 }
 
+// Get tags of VPC for cluster
+func GetVPCTagsForCluster(cluster string) []*ec2.Tag {
+	return []*ec2.Tag{
+		{
+			Key:   aws.String(CLUSTER_TAG_PREFIX + cluster),
+			Value: aws.String(CLUSTER_TAG_VALUE),
+		},
+	}
+}
+
+// Get tags of subnet for cluster, which decide where load balancers are created
+func GetSubnetTagsForCluster(cluster string, subnetType string) []*ec2.Tag {
+	elbTag := INTERNAL_ELB_TAG
+	if subnetType == SUBNET_TYPE_PUBLIC {
+		elbTag = PUBLIC_ELB_TAG
+	}
+
+	return []*ec2.Tag{
+		{
+			Key:   aws.String(CLUSTER_TAG_PREFIX + cluster),
+			Value: aws.String(CLUSTER_TAG_VALUE),
+		},
+		{
+			Key:   aws.String(elbTag),
+			Value: aws.String("1"),
+		},
+	}
+}
+
 // Update Subnet Tag for cluster
 func UpdateSubnetsTagForCluster(svc *ec2.EC2, subnets []*string, cluster string, subnetType string) error {
-	var tags []*ec2.Tag
-	if subnetType == "public" {
-		tags = []*ec2.Tag{
-			{
-				Key:   aws.String("kubernetes.io/cluster/" + cluster),
-				Value: aws.String("shared"),
-			},
-			{
-				Key:   aws.String("kubernetes.io/role/elb"),
-				Value: aws.String("1"),
-			},
-		}
-	} else {
-		tags = []*ec2.Tag{
-			{
-				Key:   aws.String("kubernetes.io/cluster/" + cluster),
-				Value: aws.String("shared"),
-			},
-			{
-				Key:   aws.String("kubernetes.io/role/runner-elb"),
-				Value: aws.String("1"),
-			},
-		}
-	}
 	inputParam := &ec2.CreateTagsInput{
 		Resources: subnets,
-		Tags:      tags,
+		Tags:      GetSubnetTagsForCluster(cluster, subnetType),
 	}
 	_, err := svc.CreateTags(inputParam)
 	if err != nil {
@@ -3,7 +3,6 @@ package aws
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/spf13/viper"
@@ -11,12 +10,16 @@ import (
 
 // Get EKS Session
 func GetEksSession(role *string) *eks.EKS {
-	awsRegion := viper.GetString("region")
+	return GetEksSessionInRegion(role, viper.GetString("region"))
+}
+
+// Get EKS Session of the region
+func GetEksSessionInRegion(role *string, awsRegion string) *eks.EKS {
 	mySession := session.Must(session.NewSession())
 
 	var creds *credentials.Credentials
 	if role != nil {
-		creds = stscreds.NewCredentials(mySession, *role)
+		creds = NewCachedAssumeRoleCredentials(awsRegion, *role)
 	}
 
 	if creds == nil {
@@ -36,3 +39,220 @@ func GetClusterInfo(svc *eks.EKS, cluster string) (*eks.DescribeClusterOutput, e
 
 	return ret, nil
 }
+
+// Get names of all clusters in the region of the session
+func ListAllClusters(svc *eks.EKS) ([]string, error) {
+	ret := []string{}
+	err := svc.ListClustersPages(&eks.ListClustersInput{}, func(page *eks.ListClustersOutput, lastPage bool) bool {
+		for _, cluster := range page.Clusters {
+			ret = append(ret, aws.StringValue(cluster))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Get names of all nodegroups in the cluster
+func ListAllNodegroups(svc *eks.EKS, cluster string) ([]string, error) {
+	ret := []string{}
+	err := svc.ListNodegroupsPages(&eks.ListNodegroupsInput{ClusterName: aws.String(cluster)}, func(page *eks.ListNodegroupsOutput, lastPage bool) bool {
+		for _, nodegroup := range page.Nodegroups {
+			ret = append(ret, aws.StringValue(nodegroup))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Get Nodegroup Information with session
+func GetNodegroupInfo(svc *eks.EKS, cluster, nodegroup string) (*eks.Nodegroup, error) {
+	inputParamsDesc := &eks.DescribeNodegroupInput{ClusterName: aws.String(cluster), NodegroupName: aws.String(nodegroup)}
+	ret, err := svc.DescribeNodegroup(inputParamsDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.Nodegroup, nil
+}
+
+// Update scaling configuration of nodegroup
+func UpdateNodegroupScaling(svc *eks.EKS, cluster, nodegroup string, scaling *eks.NodegroupScalingConfig) (*eks.Update, error) {
+	inputParams := &eks.UpdateNodegroupConfigInput{
+		ClusterName:   aws.String(cluster),
+		NodegroupName: aws.String(nodegroup),
+		ScalingConfig: scaling,
+	}
+
+	ret, err := svc.UpdateNodegroupConfig(inputParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.Update, nil
+}
+
+// Update kubernetes version, AMI release version or launch template version of nodegroup.
+// Empty version means the version of the cluster, and empty release version means the latest AMI
+func UpdateNodegroupVersion(svc *eks.EKS, cluster, nodegroup, version, releaseVersion string, launchTemplate *eks.LaunchTemplateSpecification, force bool) (*eks.Update, error) {
+	inputParams := &eks.UpdateNodegroupVersionInput{
+		ClusterName:    aws.String(cluster),
+		NodegroupName:  aws.String(nodegroup),
+		LaunchTemplate: launchTemplate,
+		Force:          aws.Bool(force),
+	}
+
+	if len(version) > 0 {
+		inputParams.Version = aws.String(version)
+	}
+
+	if len(releaseVersion) > 0 {
+		inputParams.ReleaseVersion = aws.String(releaseVersion)
+	}
+
+	ret, err := svc.UpdateNodegroupVersion(inputParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.Update, nil
+}
+
+// Get status of update of nodegroup
+func GetNodegroupUpdateInfo(svc *eks.EKS, cluster, nodegroup, updateID string) (*eks.Update, error) {
+	inputParams := &eks.DescribeUpdateInput{
+		Name:          aws.String(cluster),
+		NodegroupName: aws.String(nodegroup),
+		UpdateId:      aws.String(updateID),
+	}
+
+	ret, err := svc.DescribeUpdate(inputParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.Update, nil
+}
+
+// Get names of all fargate profiles in the cluster
+func ListAllFargateProfiles(svc *eks.EKS, cluster string) ([]string, error) {
+	ret := []string{}
+	err := svc.ListFargateProfilesPages(&eks.ListFargateProfilesInput{ClusterName: aws.String(cluster)}, func(page *eks.ListFargateProfilesOutput, lastPage bool) bool {
+		for _, profile := range page.FargateProfileNames {
+			ret = append(ret, aws.StringValue(profile))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Get Fargate Profile Information with session
+func GetFargateProfileInfo(svc *eks.EKS, cluster, profile string) (*eks.FargateProfile, error) {
+	inputParamsDesc := &eks.DescribeFargateProfileInput{ClusterName: aws.String(cluster), FargateProfileName: aws.String(profile)}
+	ret, err := svc.DescribeFargateProfile(inputParamsDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.FargateProfile, nil
+}
+
+// Get names of all add-ons installed in the cluster
+func ListAllAddons(svc *eks.EKS, cluster string) ([]string, error) {
+	ret := []string{}
+	err := svc.ListAddonsPages(&eks.ListAddonsInput{ClusterName: aws.String(cluster)}, func(page *eks.ListAddonsOutput, lastPage bool) bool {
+		for _, addon := range page.Addons {
+			ret = append(ret, aws.StringValue(addon))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Get Add-on Information with session
+func GetAddonInfo(svc *eks.EKS, cluster, addon string) (*eks.Addon, error) {
+	inputParamsDesc := &eks.DescribeAddonInput{ClusterName: aws.String(cluster), AddonName: aws.String(addon)}
+	ret, err := svc.DescribeAddon(inputParamsDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.Addon, nil
+}
+
+// Get versions of add-on compatible with the kubernetes version
+func GetAddonVersions(svc *eks.EKS, addon, kubernetesVersion string) ([]*eks.AddonVersionInfo, error) {
+	inputParams := &eks.DescribeAddonVersionsInput{AddonName: aws.String(addon), KubernetesVersion: aws.String(kubernetesVersion)}
+
+	ret := []*eks.AddonVersionInfo{}
+	err := svc.DescribeAddonVersionsPages(inputParams, func(page *eks.DescribeAddonVersionsOutput, lastPage bool) bool {
+		for _, info := range page.Addons {
+			ret = append(ret, info.AddonVersions...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Update version or configuration values of add-on.
+// Empty version and configuration values keep current ones
+func UpdateAddon(svc *eks.EKS, cluster, addon, version, resolveConflicts, configurationValues string) (*eks.Update, error) {
+	inputParams := &eks.UpdateAddonInput{
+		ClusterName: aws.String(cluster),
+		AddonName:   aws.String(addon),
+	}
+
+	if len(version) > 0 {
+		inputParams.AddonVersion = aws.String(version)
+	}
+
+	if len(resolveConflicts) > 0 {
+		inputParams.ResolveConflicts = aws.String(resolveConflicts)
+	}
+
+	if len(configurationValues) > 0 {
+		inputParams.ConfigurationValues = aws.String(configurationValues)
+	}
+
+	ret, err := svc.UpdateAddon(inputParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.Update, nil
+}
+
+// Get status of update of add-on
+func GetAddonUpdateInfo(svc *eks.EKS, cluster, addon, updateID string) (*eks.Update, error) {
+	inputParams := &eks.DescribeUpdateInput{
+		Name:      aws.String(cluster),
+		AddonName: aws.String(addon),
+		UpdateId:  aws.String(updateID),
+	}
+
+	ret, err := svc.DescribeUpdate(inputParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.Update, nil
+}
@@ -2,24 +2,27 @@ package aws
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/GwonsooLee/kubenx/pkg/utils"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/spf13/viper"
 	"io/ioutil"
 	"os"
+	"strings"
 )
 
 type KubenxAussmeConfig struct {
-	SessionName      string            `json:"session_name"`
-	Assume           map[string]string `json:"assume"`
-	EKSAssumeMapping map[string]string `json:"eks-assume-mapping"`
+	SessionName      string                      `json:"session_name"`
+	Assume           map[string]AssumeRoleConfig `json:"assume"`
+	EKSAssumeMapping map[string]string           `json:"eks-assume-mapping"`
+	Contexts         map[string]ContextMetadata  `json:"contexts"`
+
+	// Template of context names written to kubeconfig, e.g. {{.AccountAlias}}-{{.Region}}-{{.Cluster}}
+	ContextNameTemplate string `json:"context_name_template"`
 }
 
 var (
@@ -39,7 +42,7 @@ func GetIAMSession(role *string) *iam.IAM {
 
 	var creds *credentials.Credentials
 	if role != nil {
-		creds = stscreds.NewCredentials(mySession, *role)
+		creds = NewCachedAssumeRoleCredentials(awsRegion, *role)
 	}
 
 	if creds == nil {
@@ -48,14 +51,29 @@ func GetIAMSession(role *string) *iam.IAM {
 	return iam.New(mySession, &aws.Config{Region: aws.String(awsRegion), Credentials: creds})
 }
 
-func getSTSSession() *sts.STS {
-	ResetAWSEnvironmentVariable()
-
+func GetSTSSession(role *string) *sts.STS {
 	awsRegion := viper.GetString("region")
 	mySession := session.Must(session.NewSession())
-	svc := sts.New(mySession, &aws.Config{Region: aws.String(awsRegion)})
 
-	return svc
+	var creds *credentials.Credentials
+	if role != nil {
+		creds = NewCachedAssumeRoleCredentials(awsRegion, *role)
+	}
+
+	if creds == nil {
+		return sts.New(mySession, &aws.Config{Region: aws.String(awsRegion)})
+	}
+	return sts.New(mySession, &aws.Config{Region: aws.String(awsRegion), Credentials: creds})
+}
+
+// Get account ID of credentials in the session
+func GetAccountID(svc *sts.STS) (string, error) {
+	identity, err := svc.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(identity.Account), nil
 }
 
 func ResetAWSEnvironmentVariable() {
@@ -64,6 +82,20 @@ func ResetAWSEnvironmentVariable() {
 	os.Unsetenv("AWS_SESSION_TOKEN")
 }
 
+// Get alias of the account. Empty string is returned if the account has no alias
+func GetAccountAlias(svc *iam.IAM) (string, error) {
+	result, err := svc.ListAccountAliases(&iam.ListAccountAliasesInput{})
+	if err != nil {
+		return "", err
+	}
+
+	if len(result.AccountAliases) == 0 {
+		return "", nil
+	}
+
+	return aws.StringValue(result.AccountAliases[0]), nil
+}
+
 // Create Open ID Connector
 func CreateOpenIDConnector(svc *iam.IAM, issuerUrl *string) (int, error) {
 	inputParam := &iam.CreateOpenIDConnectProviderInput{
@@ -74,17 +106,33 @@ func CreateOpenIDConnector(svc *iam.IAM, issuerUrl *string) (int, error) {
 
 	_, err := svc.CreateOpenIDConnectProvider(inputParam)
 	if err != nil {
-		awsErr, _ := err.(awserr.Error)
-		if awsErr.Code() == "EntityAlreadyExists" {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeEntityAlreadyExistsException {
 			return ALREADY_EXISTS, nil
-		} else {
-			return CREATION_FAILURE, awsErr
 		}
+		return CREATION_FAILURE, err
 	}
 
 	return NEWLY_CREATED, nil
 }
 
+// Find ARN of Open ID Connector for the issuer, which is empty if not exists
+func GetOpenIDConnectorArn(svc *iam.IAM, issuerUrl *string) (string, error) {
+	result, err := svc.ListOpenIDConnectProviders(&iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		return utils.NO_STRING, err
+	}
+
+	// ARN of provider ends with issuer URL without scheme
+	suffix := "oidc-provider/" + strings.TrimPrefix(aws.StringValue(issuerUrl), "https://")
+	for _, provider := range result.OpenIDConnectProviderList {
+		if strings.HasSuffix(aws.StringValue(provider.Arn), suffix) {
+			return aws.StringValue(provider.Arn), nil
+		}
+	}
+
+	return utils.NO_STRING, nil
+}
+
 //Find Assume role mapping information
 func FindEKSAussmeInfo() (KubenxAussmeConfig, error) {
 	kubenxAssumeConfig := KubenxAussmeConfig{}
@@ -99,31 +147,20 @@ func FindEKSAussmeInfo() (KubenxAussmeConfig, error) {
 }
 
 // Create STS Assume Role
-func AssumeRole(arn string, session_name string) *sts.Credentials {
-	svc := getSTSSession()
-	input := &sts.AssumeRoleInput{
-		RoleArn:         aws.String(arn),
-		RoleSessionName: aws.String(session_name),
+func AssumeRole(reference string, session_name string) (*sts.Credentials, error) {
+	ResetAWSEnvironmentVariable()
+
+	config := FindAssumeRoleConfig(reference)
+	if len(config.RoleArn) == 0 && config.IsSSO() {
+		provider := &CachedSSOProvider{Config: config}
+		return provider.getRoleCredentials()
 	}
 
-	result, err := svc.AssumeRole(input)
-	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			case sts.ErrCodeMalformedPolicyDocumentException:
-				fmt.Println(sts.ErrCodeMalformedPolicyDocumentException, aerr.Error())
-			case sts.ErrCodePackedPolicyTooLargeException:
-				fmt.Println(sts.ErrCodePackedPolicyTooLargeException, aerr.Error())
-			case sts.ErrCodeRegionDisabledException:
-				fmt.Println(sts.ErrCodeRegionDisabledException, aerr.Error())
-			default:
-				fmt.Println(aerr.Error())
-			}
-		} else {
-			fmt.Println(err.Error())
-		}
-		return nil
+	provider := &CachedAssumeRoleProvider{
+		Region:      viper.GetString("region"),
+		Config:      config,
+		SessionName: session_name,
 	}
 
-	return result.Credentials
+	return provider.assumeRole()
 }
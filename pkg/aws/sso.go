@@ -0,0 +1,234 @@
+package aws
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sso"
+	"github.com/aws/aws-sdk-go/service/ssooidc"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	SSO_CLIENT_NAME       = "kubenx"
+	SSO_CLIENT_TYPE       = "public"
+	SSO_DEVICE_GRANT_TYPE = "urn:ietf:params:oauth:grant-type:device_code"
+	SSO_CACHE_DIR         = filepath.Join(utils.HomeDir(), ".aws", "sso", "cache")
+
+	// Time formats of expiresAt written by AWS CLI
+	SSO_EXPIRES_AT_FORMATS = []string{time.RFC3339, "2006-01-02T15:04:05UTC"}
+)
+
+// SSO access token shared with AWS CLI
+type SSOToken struct {
+	StartURL    string `json:"startUrl"`
+	Region      string `json:"region"`
+	AccessToken string `json:"accessToken"`
+	ExpiresAt   string `json:"expiresAt"`
+}
+
+// Check whether the token is still valid
+func (t SSOToken) IsValid() bool {
+	if len(t.AccessToken) == 0 {
+		return false
+	}
+
+	for _, format := range SSO_EXPIRES_AT_FORMATS {
+		if expiresAt, err := time.Parse(format, t.ExpiresAt); err == nil {
+			return time.Now().Add(CREDENTIAL_EXPIRY_WINDOW).Before(expiresAt)
+		}
+	}
+
+	return false
+}
+
+// Check whether the assume configuration uses AWS SSO
+func (c AssumeRoleConfig) IsSSO() bool {
+	return len(c.SSOStartURL) > 0
+}
+
+// Credential provider with AWS SSO role credentials and on-disk cache
+type CachedSSOProvider struct {
+	credentials.Expiry
+
+	Config AssumeRoleConfig
+}
+
+// Retrieve role credentials of permission set from cache or AWS SSO
+func (p *CachedSSOProvider) Retrieve() (credentials.Value, error) {
+	creds, err := p.getRoleCredentials()
+	if err != nil {
+		return credentials.Value{ProviderName: CACHED_ASSUME_ROLE_PROVIDER_NAME}, err
+	}
+
+	p.SetExpiration(*creds.Expiration, CREDENTIAL_EXPIRY_WINDOW)
+
+	return getCredentialValue(creds), nil
+}
+
+// Get role credentials only if credentials in cache are not valid
+func (p *CachedSSOProvider) getRoleCredentials() (*sts.Credentials, error) {
+	cachePath := getCredentialCachePath("sso", p.Config.SSOStartURL, p.Config.SSOAccountID, p.Config.SSORoleName)
+	if creds, err := readCredentialCache(cachePath); err == nil {
+		return creds, nil
+	}
+
+	mySession, err := session.NewSession(&aws.Config{Region: aws.String(p.Config.SSORegion)})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getSSOAccessToken(mySession, p.Config.SSOStartURL, p.Config.SSORegion)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := sso.New(mySession).GetRoleCredentials(&sso.GetRoleCredentialsInput{
+		AccessToken: aws.String(token.AccessToken),
+		AccountId:   aws.String(p.Config.SSOAccountID),
+		RoleName:    aws.String(p.Config.SSORoleName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	roleCreds := result.RoleCredentials
+	creds := &sts.Credentials{
+		AccessKeyId:     roleCreds.AccessKeyId,
+		SecretAccessKey: roleCreds.SecretAccessKey,
+		SessionToken:    roleCreds.SessionToken,
+		Expiration:      aws.Time(time.Unix(0, aws.Int64Value(roleCreds.Expiration)*int64(time.Millisecond))),
+	}
+
+	// Failure of caching should not block authentication
+	_ = writeCredentialCache(cachePath, creds)
+
+	return creds, nil
+}
+
+// Get SSO access token from AWS CLI cache, or run device authorization if it is expired
+func getSSOAccessToken(mySession *session.Session, startURL, region string) (SSOToken, error) {
+	// Prompt only once even if several roles need the token at the same time
+	promptLock.Lock()
+	defer promptLock.Unlock()
+
+	cachePath := getSSOTokenCachePath(startURL)
+	if token, err := readSSOTokenCache(cachePath); err == nil && token.IsValid() {
+		return token, nil
+	}
+
+	token, err := authorizeSSODevice(ssooidc.New(mySession), startURL, region)
+	if err != nil {
+		return token, err
+	}
+
+	// Failure of caching should not block authentication
+	_ = writeSSOTokenCache(cachePath, token)
+
+	return token, nil
+}
+
+// Run device authorization flow and wait until user approves it in browser
+func authorizeSSODevice(svc *ssooidc.SSOOIDC, startURL, region string) (SSOToken, error) {
+	client, err := svc.RegisterClient(&ssooidc.RegisterClientInput{
+		ClientName: aws.String(SSO_CLIENT_NAME),
+		ClientType: aws.String(SSO_CLIENT_TYPE),
+	})
+	if err != nil {
+		return SSOToken{}, err
+	}
+
+	authorization, err := svc.StartDeviceAuthorization(&ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     client.ClientId,
+		ClientSecret: client.ClientSecret,
+		StartUrl:     aws.String(startURL),
+	})
+	if err != nil {
+		return SSOToken{}, err
+	}
+
+	// Output should not be mixed with stdout of commands like token
+	fmt.Fprintf(os.Stderr, "Open the URL below in browser and confirm the code %s\n%s\n", aws.StringValue(authorization.UserCode), aws.StringValue(authorization.VerificationUriComplete))
+
+	interval := time.Duration(aws.Int64Value(authorization.Interval)) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(aws.Int64Value(authorization.ExpiresIn)) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		result, err := svc.CreateToken(&ssooidc.CreateTokenInput{
+			ClientId:     client.ClientId,
+			ClientSecret: client.ClientSecret,
+			DeviceCode:   authorization.DeviceCode,
+			GrantType:    aws.String(SSO_DEVICE_GRANT_TYPE),
+		})
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok {
+				switch aerr.Code() {
+				case ssooidc.ErrCodeAuthorizationPendingException:
+					continue
+				case ssooidc.ErrCodeSlowDownException:
+					interval += 5 * time.Second
+					continue
+				}
+			}
+			return SSOToken{}, err
+		}
+
+		return SSOToken{
+			StartURL:    startURL,
+			Region:      region,
+			AccessToken: aws.StringValue(result.AccessToken),
+			ExpiresAt:   time.Now().Add(time.Duration(aws.Int64Value(result.ExpiresIn)) * time.Second).UTC().Format(time.RFC3339),
+		}, nil
+	}
+
+	return SSOToken{}, fmt.Errorf("device authorization for %s has expired", startURL)
+}
+
+// Get cache file path of SSO token used by AWS CLI
+func getSSOTokenCachePath(startURL string) string {
+	sum := sha1.Sum([]byte(startURL))
+	return filepath.Join(SSO_CACHE_DIR, hex.EncodeToString(sum[:])+".json")
+}
+
+// Read SSO token from cache
+func readSSOTokenCache(path string) (SSOToken, error) {
+	token := SSOToken{}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return token, err
+	}
+
+	err = json.Unmarshal(raw, &token)
+	return token, err
+}
+
+// Write SSO token to cache only readable by owner
+func writeSSOTokenCache(path string, token SSOToken) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomically(path, raw)
+}
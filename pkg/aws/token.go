@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	EKS_TOKEN_PREFIX      = "k8s-aws-v1."
+	EKS_CLUSTER_ID_HEADER = "x-k8s-aws-id"
+	EXEC_CREDENTIAL_KIND  = "ExecCredential"
+
+	// Presigned URL is valid for 15 minutes, so token is refreshed a bit earlier
+	EKS_TOKEN_PRESIGN_DURATION = 60 * time.Second
+	EKS_TOKEN_EXPIRATION       = 14 * time.Minute
+	EKS_TOKEN_REFRESH_WINDOW   = 1 * time.Minute
+
+	TOKEN_CACHE_DIR = filepath.Join(utils.HomeDir(), utils.KUBENX_HOMEDIR, "cache", "token")
+)
+
+// ExecCredential for client-go credential plugin
+type ExecCredential struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Spec       map[string]string     `json:"spec"`
+	Status     *ExecCredentialStatus `json:"status,omitempty"`
+}
+
+// Status of ExecCredential with token
+type ExecCredentialStatus struct {
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+	Token               string    `json:"token"`
+}
+
+// Get ExecCredential of EKS token, using cache until it expires
+func GetEKSExecCredential(cluster, reference, sessionName, region, apiVersion string) (*ExecCredential, error) {
+	cachePath := getTokenCachePath(cluster, reference, region)
+	if credential, err := readTokenCache(cachePath); err == nil && time.Now().Add(EKS_TOKEN_REFRESH_WINDOW).Before(credential.Status.ExpirationTimestamp) {
+		credential.APIVersion = apiVersion
+		return credential, nil
+	}
+
+	token, expiration, err := GetEKSToken(cluster, reference, sessionName, region)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &ExecCredential{
+		APIVersion: apiVersion,
+		Kind:       EXEC_CREDENTIAL_KIND,
+		Spec:       map[string]string{},
+		Status: &ExecCredentialStatus{
+			ExpirationTimestamp: expiration,
+			Token:               token,
+		},
+	}
+
+	// Failure of caching should not block authentication
+	_ = writeTokenCache(cachePath, credential)
+
+	return credential, nil
+}
+
+// Generate EKS token with presigned STS GetCallerIdentity request. Reference is role ARN or alias in assume configuration
+func GetEKSToken(cluster, reference, sessionName, region string) (string, time.Time, error) {
+	mySession, err := session.NewSession(&aws.Config{
+		Region:              aws.String(region),
+		STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+	})
+	if err != nil {
+		return utils.NO_STRING, time.Time{}, err
+	}
+
+	svc := sts.New(mySession)
+	if len(reference) > 0 {
+		creds := credentials.NewCredentials(newAssumeProvider(region, FindAssumeRoleConfig(reference), sessionName, 0))
+		svc = sts.New(mySession, &aws.Config{Credentials: creds})
+	}
+
+	request, _ := svc.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	request.HTTPRequest.Header.Add(EKS_CLUSTER_ID_HEADER, cluster)
+
+	presigned, err := request.Presign(EKS_TOKEN_PRESIGN_DURATION)
+	if err != nil {
+		return utils.NO_STRING, time.Time{}, err
+	}
+
+	token := EKS_TOKEN_PREFIX + base64.RawURLEncoding.EncodeToString([]byte(presigned))
+	return token, time.Now().Add(EKS_TOKEN_EXPIRATION).UTC(), nil
+}
+
+// Get cache file path of token
+func getTokenCachePath(cluster, reference, region string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s", cluster, reference, region)))
+	return filepath.Join(TOKEN_CACHE_DIR, hex.EncodeToString(sum[:])+".json")
+}
+
+// Read cached ExecCredential
+func readTokenCache(path string) (*ExecCredential, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &ExecCredential{}
+	if err := json.Unmarshal(raw, credential); err != nil {
+		return nil, err
+	}
+
+	if credential.Status == nil {
+		return nil, fmt.Errorf("no token exists in cache %s", path)
+	}
+
+	return credential, nil
+}
+
+// Write ExecCredential to cache only readable by owner
+func writeTokenCache(path string, credential *ExecCredential) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, raw, 0600)
+}
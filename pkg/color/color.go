@@ -18,8 +18,31 @@ var (
 	Green  = Color{color: color.New(color.FgGreen)}
 	Yellow = Color{color: color.New(color.FgYellow)}
 	Cyan   = Color{color: color.New(color.FgCyan)}
+
+	Magenta = Color{color: color.New(color.FgMagenta)}
+	Default = Color{}
 )
 
+// Get color by name used in configuration. Unknown name gets default color
+func ByName(name string) Color {
+	switch strings.ToLower(name) {
+	case "red":
+		return Red
+	case "blue":
+		return Blue
+	case "green":
+		return Green
+	case "yellow":
+		return Yellow
+	case "cyan":
+		return Cyan
+	case "magenta":
+		return Magenta
+	}
+
+	return Default
+}
+
 // Fprintln outputs the result to out, followed by a newline.
 func (c Color) Fprintln(out io.Writer, a ...interface{}) {
 	if c.color == nil {
@@ -0,0 +1,167 @@
+package history
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	HISTORY_FILE_PATH = filepath.Join(utils.HomeDir(), utils.KUBENX_HOMEDIR, "history")
+
+	// Maximum number of entries kept for contexts and namespaces
+	MAX_HISTORY_SIZE = 50
+)
+
+// Switch of context or namespace
+type Entry struct {
+	Context   string    `json:"context"`
+	Namespace string    `json:"namespace,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// History of switches in recent-first order
+type History struct {
+	Contexts   []Entry `json:"contexts"`
+	Namespaces []Entry `json:"namespaces"`
+}
+
+// Load history file. Empty history is returned if the file does not exist
+func Load() (*History, error) {
+	history := &History{}
+	raw, err := ioutil.ReadFile(HISTORY_FILE_PATH)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Save history file
+func (h *History) Save() error {
+	if err := os.MkdirAll(filepath.Dir(HISTORY_FILE_PATH), 0700); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomically(HISTORY_FILE_PATH, raw)
+}
+
+// Record switch from one context to another
+func (h *History) AddContext(from, to string) {
+	now := time.Now()
+	if len(from) > 0 {
+		h.Contexts = pushEntry(h.Contexts, Entry{Context: from, Time: now})
+	}
+	h.Contexts = pushEntry(h.Contexts, Entry{Context: to, Time: now})
+}
+
+// Record switch from one namespace to another in the context.
+// Empty namespace is recorded as well, which means the default namespace of the context
+func (h *History) AddNamespace(context, from, to string) {
+	now := time.Now()
+	h.Namespaces = pushEntry(h.Namespaces, Entry{Context: context, Namespace: from, Time: now})
+	h.Namespaces = pushEntry(h.Namespaces, Entry{Context: context, Namespace: to, Time: now})
+}
+
+// Get the most recent context other than current one
+func (h *History) PreviousContext(current string) (string, bool) {
+	for _, entry := range h.Contexts {
+		if entry.Context != current {
+			return entry.Context, true
+		}
+	}
+
+	return utils.NO_STRING, false
+}
+
+// Get the most recent namespace of the context other than current one
+func (h *History) PreviousNamespace(context, current string) (string, bool) {
+	for _, entry := range h.Namespaces {
+		if entry.Context == context && entry.Namespace != current {
+			return entry.Namespace, true
+		}
+	}
+
+	return utils.NO_STRING, false
+}
+
+// Get recently used contexts in recent-first order
+func (h *History) RecentContexts() []string {
+	ret := []string{}
+	for _, entry := range h.Contexts {
+		ret = append(ret, entry.Context)
+	}
+
+	return ret
+}
+
+// Get recently used namespaces of the context in recent-first order
+func (h *History) RecentNamespaces(context string) []string {
+	ret := []string{}
+	for _, entry := range h.Namespaces {
+		if entry.Context == context {
+			ret = append(ret, entry.Namespace)
+		}
+	}
+
+	return ret
+}
+
+// Sort options with recently used ones first, and others in alphabetical order
+func SortByRecent(options, recent []string) []string {
+	rank := map[string]int{}
+	for i, name := range recent {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+
+	sorted := append([]string{}, options...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, iok := rank[sorted[i]]
+		rj, jok := rank[sorted[j]]
+		switch {
+		case iok && jok:
+			return ri < rj
+		case iok != jok:
+			return iok
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted
+}
+
+// Move entry to the front, removing the same one
+func pushEntry(entries []Entry, entry Entry) []Entry {
+	ret := []Entry{entry}
+	for _, e := range entries {
+		if e.Context == entry.Context && e.Namespace == entry.Namespace {
+			continue
+		}
+		ret = append(ret, e)
+	}
+
+	if len(ret) > MAX_HISTORY_SIZE {
+		ret = ret[:MAX_HISTORY_SIZE]
+	}
+
+	return ret
+}
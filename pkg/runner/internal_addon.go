@@ -0,0 +1,201 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	kubenxaws "github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+var (
+	// Options of resolving conflicts when add-on is updated
+	ADDON_RESOLVE_CONFLICTS = []string{eks.ResolveConflictsNone, eks.ResolveConflictsOverwrite, eks.ResolveConflictsPreserve}
+)
+
+// Installed add-on with versions compatible with kubernetes version of the cluster
+type AddonStatus struct {
+	Addon          *eks.Addon
+	LatestVersion  string
+	DefaultVersion string
+}
+
+// Check whether newer compatible version than installed one exists
+func (a AddonStatus) IsUpdateAvailable() bool {
+	return IsNewerAddonVersion(a.LatestVersion, aws.StringValue(a.Addon.AddonVersion))
+}
+
+// Get Add-on for describe or update
+func GetTargetAddon(svc *eks.EKS, cluster string, args []string) (string, error) {
+	// Pass from command
+	if len(args) == 1 {
+		return args[0], nil
+	}
+
+	options, err := kubenxaws.ListAllAddons(svc, cluster)
+	if err != nil {
+		return utils.NO_STRING, err
+	}
+
+	if len(options) == 0 {
+		return utils.NO_STRING, fmt.Errorf("No add-on is installed in %s", cluster)
+	}
+
+	var addon string
+	prompt := &survey.Select{
+		Message: "Choose an add-on:",
+		Options: options,
+	}
+	survey.AskOne(prompt, &addon)
+
+	if len(addon) == 0 {
+		return utils.NO_STRING, fmt.Errorf("No add-on is selected")
+	}
+
+	return addon, nil
+}
+
+// Describe add-on with latest and default versions compatible with the kubernetes version
+func GetAddonStatus(svc *eks.EKS, cluster, addon, kubernetesVersion string) (AddonStatus, error) {
+	info, err := kubenxaws.GetAddonInfo(svc, cluster, addon)
+	if err != nil {
+		return AddonStatus{}, err
+	}
+
+	versions, err := kubenxaws.GetAddonVersions(svc, addon, kubernetesVersion)
+	if err != nil {
+		return AddonStatus{}, err
+	}
+
+	latest, defaultVersion := getCompatibleAddonVersions(versions, kubernetesVersion)
+
+	return AddonStatus{Addon: info, LatestVersion: latest, DefaultVersion: defaultVersion}, nil
+}
+
+// Describe all add-ons installed in the cluster
+func GetAllAddonStatus(svc *eks.EKS, cluster, kubernetesVersion string) ([]AddonStatus, error) {
+	names, err := kubenxaws.ListAllAddons(svc, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []AddonStatus{}
+	for _, name := range names {
+		status, err := GetAddonStatus(svc, cluster, name, kubernetesVersion)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, status)
+	}
+
+	return ret, nil
+}
+
+// Check whether the target version is newer than current one.
+// Versions which cannot be parsed are regarded as different
+func IsNewerAddonVersion(target, current string) bool {
+	if len(target) == 0 {
+		return false
+	}
+
+	targetVersion, err := version.ParseSemantic(target)
+	if err != nil {
+		return target != current
+	}
+
+	currentVersion, err := version.ParseSemantic(current)
+	if err != nil {
+		return target != current
+	}
+
+	return currentVersion.LessThan(targetVersion)
+}
+
+// Get latest and default versions of add-on for the kubernetes version
+func getCompatibleAddonVersions(versions []*eks.AddonVersionInfo, kubernetesVersion string) (string, string) {
+	latest, defaultVersion := utils.NO_STRING, utils.NO_STRING
+	for _, info := range versions {
+		addonVersion := aws.StringValue(info.AddonVersion)
+		for _, compatibility := range info.Compatibilities {
+			if aws.StringValue(compatibility.ClusterVersion) != kubernetesVersion {
+				continue
+			}
+
+			if aws.BoolValue(compatibility.DefaultVersion) {
+				defaultVersion = addonVersion
+			}
+
+			if len(latest) == 0 || IsNewerAddonVersion(addonVersion, latest) {
+				latest = addonVersion
+			}
+		}
+	}
+
+	return latest, defaultVersion
+}
+
+// Render Add-on list
+func RenderAddonListInfo(addons []AddonStatus) bool {
+	if len(addons) <= 0 {
+		return false
+	}
+
+	now := time.Now()
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader([]string{"NAME", "VERSION", "LATEST", "STATUS", "UPDATE", "ISSUES", "AGE"})
+
+	for _, addon := range addons {
+		update := "-"
+		if addon.IsUpdateAvailable() {
+			update = "available"
+		}
+
+		issues := 0
+		if addon.Addon.Health != nil {
+			issues = len(addon.Addon.Health.Issues)
+		}
+
+		table.Append([]string{
+			aws.StringValue(addon.Addon.AddonName),
+			aws.StringValue(addon.Addon.AddonVersion),
+			addon.LatestVersion,
+			aws.StringValue(addon.Addon.Status),
+			update,
+			fmt.Sprintf("%d", issues),
+			duration.HumanDuration(now.Sub(aws.TimeValue(addon.Addon.CreatedAt))),
+		})
+	}
+	table.Render()
+
+	return true
+}
+
+// Render detail of add-on
+func RenderAddonDetail(addon AddonStatus) {
+	info := addon.Addon
+
+	table := table.GetTableObject()
+	table.SetHeader([]string{"NAME", aws.StringValue(info.AddonName)})
+	table.Append([]string{"Status", aws.StringValue(info.Status)})
+	table.Append([]string{"Version", aws.StringValue(info.AddonVersion)})
+	table.Append([]string{"Latest Compatible Version", addon.LatestVersion})
+	table.Append([]string{"Default Version", addon.DefaultVersion})
+	table.Append([]string{"Service Account Role", aws.StringValue(info.ServiceAccountRoleArn)})
+	table.Append([]string{"Arn", aws.StringValue(info.AddonArn)})
+	table.Append([]string{"Modified At", aws.TimeValue(info.ModifiedAt).Format(time.RFC3339)})
+
+	if info.Health != nil {
+		for _, issue := range info.Health.Issues {
+			table.Append([]string{"Health Issue", fmt.Sprintf("%s: %s", aws.StringValue(issue.Code), aws.StringValue(issue.Message))})
+		}
+	}
+	table.Render()
+}
@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+func TestIsNewerAddonVersion(t *testing.T) {
+	tcs := []struct {
+		name     string
+		target   string
+		current  string
+		expected bool
+	}{
+		{name: "newer patch", target: "v1.7.5-eksbuild.2", current: "v1.7.5-eksbuild.1", expected: true},
+		{name: "newer minor", target: "v1.8.0-eksbuild.1", current: "v1.7.5-eksbuild.2", expected: true},
+		{name: "same version", target: "v1.7.5-eksbuild.1", current: "v1.7.5-eksbuild.1", expected: false},
+		{name: "older version", target: "v1.6.0-eksbuild.1", current: "v1.7.5-eksbuild.1", expected: false},
+		{name: "empty target", target: "", current: "v1.7.5-eksbuild.1", expected: false},
+		{name: "empty current", target: "v1.7.5-eksbuild.1", current: "", expected: true},
+		{name: "unparsable different version", target: "latest", current: "v1.7.5-eksbuild.1", expected: true},
+		{name: "unparsable same version", target: "latest", current: "latest", expected: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if ret := IsNewerAddonVersion(tc.target, tc.current); ret != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, ret)
+			}
+		})
+	}
+}
+
+// Get add-on version compatible with the kubernetes versions
+func newTestAddonVersion(addonVersion string, defaultOf string, clusterVersions ...string) *eks.AddonVersionInfo {
+	info := &eks.AddonVersionInfo{AddonVersion: aws.String(addonVersion)}
+	for _, clusterVersion := range clusterVersions {
+		info.Compatibilities = append(info.Compatibilities, &eks.Compatibility{
+			ClusterVersion: aws.String(clusterVersion),
+			DefaultVersion: aws.Bool(clusterVersion == defaultOf),
+		})
+	}
+
+	return info
+}
+
+func TestGetCompatibleAddonVersions(t *testing.T) {
+	versions := []*eks.AddonVersionInfo{
+		newTestAddonVersion("v1.8.0-eksbuild.1", "", "1.18"),
+		newTestAddonVersion("v1.7.5-eksbuild.2", "1.17", "1.17", "1.18"),
+		newTestAddonVersion("v1.7.5-eksbuild.1", "1.16", "1.16", "1.17"),
+		newTestAddonVersion("v1.6.3-eksbuild.1", "", "1.16"),
+	}
+
+	tcs := []struct {
+		name              string
+		kubernetesVersion string
+		expectedLatest    string
+		expectedDefault   string
+	}{
+		{name: "latest is not default", kubernetesVersion: "1.18", expectedLatest: "v1.8.0-eksbuild.1", expectedDefault: ""},
+		{name: "latest is default", kubernetesVersion: "1.17", expectedLatest: "v1.7.5-eksbuild.2", expectedDefault: "v1.7.5-eksbuild.2"},
+		{name: "older cluster", kubernetesVersion: "1.16", expectedLatest: "v1.7.5-eksbuild.1", expectedDefault: "v1.7.5-eksbuild.1"},
+		{name: "no compatible version", kubernetesVersion: "1.15", expectedLatest: "", expectedDefault: ""},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			latest, defaultVersion := getCompatibleAddonVersions(versions, tc.kubernetesVersion)
+			if latest != tc.expectedLatest || defaultVersion != tc.expectedDefault {
+				t.Errorf("expected (%q, %q), got (%q, %q)", tc.expectedLatest, tc.expectedDefault, latest, defaultVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/GwonsooLee/kubenx/pkg/table"
+)
+
+var (
+	PEM_CERTIFICATE_HEADER = []byte("-----BEGIN CERTIFICATE-----")
+)
+
+// Check whether data has PEM encoded certificate
+func IsPEMCertificate(data []byte) bool {
+	return bytes.Contains(data, PEM_CERTIFICATE_HEADER)
+}
+
+// Parse all certificates in PEM encoded chain
+func ParsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate exists in PEM data")
+	}
+
+	return certs, nil
+}
+
+// Get all subject alternative names of certificate
+func GetSubjectAlternativeNames(cert *x509.Certificate) []string {
+	sans := []string{}
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	return sans
+}
+
+// Get days remaining until the certificate expires
+func GetDaysUntilExpiry(cert *x509.Certificate, now time.Time) int {
+	return int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+}
+
+// Certificate found in cluster resources
+type CertificateExpiry struct {
+	Source      string
+	Namespace   string
+	Name        string
+	Certificate *x509.Certificate
+	DaysLeft    int
+}
+
+// Get expiry information of all certificates in PEM data
+func GetCertificateExpiries(source, namespace, name string, data []byte, now time.Time) ([]CertificateExpiry, error) {
+	certs, err := ParsePEMCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []CertificateExpiry{}
+	for _, cert := range certs {
+		ret = append(ret, CertificateExpiry{
+			Source:      source,
+			Namespace:   namespace,
+			Name:        name,
+			Certificate: cert,
+			DaysLeft:    GetDaysUntilExpiry(cert, now),
+		})
+	}
+
+	return ret, nil
+}
+
+// Render certificate expiry list
+func RenderCertificateExpiryList(expiries []CertificateExpiry) bool {
+	if len(expiries) <= 0 {
+		return false
+	}
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader([]string{"SOURCE", "NAMESPACE", "NAME", "SUBJECT", "NOT AFTER", "DAYS LEFT"})
+
+	for _, expiry := range expiries {
+		table.Append([]string{expiry.Source, expiry.Namespace, expiry.Name, expiry.Certificate.Subject.String(), expiry.Certificate.NotAfter.Format(time.RFC3339), strconv.Itoa(expiry.DaysLeft)})
+	}
+	table.Render()
+
+	return true
+}
@@ -0,0 +1,209 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	kubenxaws "github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var (
+	// Subnets whose name starts with the prefix are not used by the cluster
+	DB_SUBNET_PREFIX = "db"
+)
+
+// Tag which will be added or changed
+type TagChange struct {
+	Key     string
+	Value   string
+	Current string
+	Exists  bool
+}
+
+// Tags of AWS resource which will be changed by cluster init
+type ResourceTagChange struct {
+	ResourceId string
+	Name       string
+	Changes    []TagChange
+}
+
+// Changes of AWS resources for initializing the cluster
+type ClusterInitPlan struct {
+	Cluster         string
+	VpcId           string
+	VPC             []ResourceTagChange
+	PublicSubnets   []ResourceTagChange
+	PrivateSubnets  []ResourceTagChange
+	OIDCIssuer      string
+	OIDCProviderArn string
+}
+
+// Check whether any AWS resource will be changed
+func (p *ClusterInitPlan) HasChanges() bool {
+	return len(p.VPC) > 0 || len(p.PublicSubnets) > 0 || len(p.PrivateSubnets) > 0 || p.NeedsOIDCProvider()
+}
+
+// Check whether OIDC provider will be created
+func (p *ClusterInitPlan) NeedsOIDCProvider() bool {
+	return len(p.OIDCIssuer) > 0 && len(p.OIDCProviderArn) == 0
+}
+
+// Get IDs of resources in tag changes
+func GetResourceIds(changes []ResourceTagChange) []*string {
+	ret := []*string{}
+	for _, change := range changes {
+		ret = append(ret, aws.String(change.ResourceId))
+	}
+
+	return ret
+}
+
+// Find tags and OIDC provider which cluster init will change without changing anything
+func GetClusterInitPlan(ec2Svc *ec2.EC2, iamSvc *iam.IAM, cluster *eks.Cluster) (*ClusterInitPlan, error) {
+	name := aws.StringValue(cluster.Name)
+	vpcId := cluster.ResourcesVpcConfig.VpcId
+
+	plan := &ClusterInitPlan{
+		Cluster:        name,
+		VpcId:          aws.StringValue(vpcId),
+		VPC:            []ResourceTagChange{},
+		PublicSubnets:  []ResourceTagChange{},
+		PrivateSubnets: []ResourceTagChange{},
+	}
+
+	// 1. VPC
+	vpcInfo, err := kubenxaws.GetVPCInfo(ec2Svc, vpcId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vpcInfo.Vpcs) == 0 {
+		return nil, fmt.Errorf("VPC %s does not exist", plan.VpcId)
+	}
+
+	vpc := vpcInfo.Vpcs[0]
+	if changes := getTagChanges(vpc.Tags, kubenxaws.GetVPCTagsForCluster(name)); len(changes) > 0 {
+		plan.VPC = append(plan.VPC, ResourceTagChange{ResourceId: plan.VpcId, Name: getNameTag(vpc.Tags), Changes: changes})
+	}
+
+	// 2. Public and private subnets in the VPC
+	subnetList, err := kubenxaws.GetSubnetListInVPC(ec2Svc, vpcId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subnetList.Subnets) == 0 {
+		return nil, fmt.Errorf("No subnet exists, please checkout out VPC")
+	}
+
+	for _, subnet := range subnetList.Subnets {
+		subnetName := getNameTag(subnet.Tags)
+		if strings.HasPrefix(subnetName, DB_SUBNET_PREFIX) {
+			continue
+		}
+
+		subnetType := kubenxaws.SUBNET_TYPE_PRIVATE
+		if aws.BoolValue(subnet.MapPublicIpOnLaunch) {
+			subnetType = kubenxaws.SUBNET_TYPE_PUBLIC
+		}
+
+		changes := getTagChanges(subnet.Tags, kubenxaws.GetSubnetTagsForCluster(name, subnetType))
+		if len(changes) == 0 {
+			continue
+		}
+
+		change := ResourceTagChange{ResourceId: aws.StringValue(subnet.SubnetId), Name: subnetName, Changes: changes}
+		if subnetType == kubenxaws.SUBNET_TYPE_PUBLIC {
+			plan.PublicSubnets = append(plan.PublicSubnets, change)
+		} else {
+			plan.PrivateSubnets = append(plan.PrivateSubnets, change)
+		}
+	}
+
+	// 3. OIDC provider
+	if cluster.Identity != nil && cluster.Identity.Oidc != nil {
+		plan.OIDCIssuer = aws.StringValue(cluster.Identity.Oidc.Issuer)
+
+		arn, err := kubenxaws.GetOpenIDConnectorArn(iamSvc, cluster.Identity.Oidc.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		plan.OIDCProviderArn = arn
+	}
+
+	return plan, nil
+}
+
+// Get tags which are missing or have different values
+func getTagChanges(current []*ec2.Tag, desired []*ec2.Tag) []TagChange {
+	ret := []TagChange{}
+	for _, tag := range desired {
+		change := TagChange{Key: aws.StringValue(tag.Key), Value: aws.StringValue(tag.Value)}
+		for _, existing := range current {
+			if aws.StringValue(existing.Key) == change.Key {
+				change.Current = aws.StringValue(existing.Value)
+				change.Exists = true
+			}
+		}
+
+		if !change.Exists || change.Current != change.Value {
+			ret = append(ret, change)
+		}
+	}
+
+	return ret
+}
+
+// Get value of Name tag
+func getNameTag(tags []*ec2.Tag) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == "Name" {
+			return aws.StringValue(tag.Value)
+		}
+	}
+
+	return utils.NO_STRING
+}
+
+// Render changes of cluster init
+func RenderClusterInitPlan(plan *ClusterInitPlan) {
+	table := table.GetTableObject()
+	table.SetHeader([]string{"STEP", "RESOURCE", "NAME", "CHANGE"})
+
+	appendTagChanges := func(step string, resources []ResourceTagChange) {
+		if len(resources) == 0 {
+			table.Append([]string{step, "-", "-", "already updated"})
+			return
+		}
+
+		for _, resource := range resources {
+			for _, change := range resource.Changes {
+				line := fmt.Sprintf("add tag %s=%s", change.Key, change.Value)
+				if change.Exists {
+					line = fmt.Sprintf("change tag %s=%s (current: %s)", change.Key, change.Value, change.Current)
+				}
+				table.Append([]string{step, resource.ResourceId, resource.Name, line})
+			}
+		}
+	}
+
+	appendTagChanges("1. VPC", plan.VPC)
+	appendTagChanges("2. Public Subnet", plan.PublicSubnets)
+	appendTagChanges("3. Private Subnet", plan.PrivateSubnets)
+
+	if plan.NeedsOIDCProvider() {
+		table.Append([]string{"4. OIDC Provider", plan.OIDCIssuer, "-", "create provider for sts.amazonaws.com"})
+	} else if len(plan.OIDCProviderArn) > 0 {
+		table.Append([]string{"4. OIDC Provider", plan.OIDCProviderArn, "-", "already exists"})
+	} else {
+		table.Append([]string{"4. OIDC Provider", "-", "-", "cluster has no OIDC issuer"})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestGetTagChanges(t *testing.T) {
+	tag := func(key, value string) *ec2.Tag {
+		return &ec2.Tag{Key: aws.String(key), Value: aws.String(value)}
+	}
+	desired := []*ec2.Tag{tag("kubernetes.io/cluster/prod", "shared"), tag("kubernetes.io/role/elb", "1")}
+
+	tcs := []struct {
+		name     string
+		current  []*ec2.Tag
+		expected []TagChange
+	}{
+		{
+			name:    "no tags",
+			current: nil,
+			expected: []TagChange{
+				{Key: "kubernetes.io/cluster/prod", Value: "shared"},
+				{Key: "kubernetes.io/role/elb", Value: "1"},
+			},
+		},
+		{
+			name:     "already tagged",
+			current:  []*ec2.Tag{tag("Name", "public-a"), tag("kubernetes.io/role/elb", "1"), tag("kubernetes.io/cluster/prod", "shared")},
+			expected: []TagChange{},
+		},
+		{
+			name:     "different value",
+			current:  []*ec2.Tag{tag("kubernetes.io/cluster/prod", "owned"), tag("kubernetes.io/role/elb", "1")},
+			expected: []TagChange{{Key: "kubernetes.io/cluster/prod", Value: "shared", Current: "owned", Exists: true}},
+		},
+		{
+			name:     "empty value",
+			current:  []*ec2.Tag{tag("kubernetes.io/cluster/prod", "shared"), tag("kubernetes.io/role/elb", "")},
+			expected: []TagChange{{Key: "kubernetes.io/role/elb", Value: "1", Current: "", Exists: true}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := getTagChanges(tc.current, desired)
+			if !reflect.DeepEqual(changes, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, changes)
+			}
+		})
+	}
+}
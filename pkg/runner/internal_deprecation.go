@@ -0,0 +1,355 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/GwonsooLee/kubenx/pkg/table"
+)
+
+var (
+	// Annotation written by kubectl apply with the manifest applied last time
+	LAST_APPLIED_ANNOTATION = "kubectl.kubernetes.io/last-applied-configuration"
+
+	DEPRECATION_SOURCE_LAST_APPLIED  = "last-applied"
+	DEPRECATION_SOURCE_MANAGED_FIELD = "managed-fields"
+
+	// Objects are listed in pages, because large clusters have too many objects of a kind for one response
+	DEPRECATION_SCAN_PAGE_SIZE int64 = 500
+
+	// Managed fields keep apiVersion of a manager until it writes again, so it could be stale
+	DEPRECATION_WARNING_MANAGED_FIELDS = "only managed fields use the API, which could be stale"
+
+	// API versions deprecated or removed in kubernetes releases.
+	// https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+	DEPRECATED_APIS = []DeprecatedAPI{
+		// Removed in 1.16
+		{APIVersion: "extensions/v1beta1", Kind: "Deployment", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "extensions/v1beta1", Kind: "DaemonSet", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "extensions/v1beta1", Kind: "ReplicaSet", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "extensions/v1beta1", Kind: "NetworkPolicy", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "networking.k8s.io/v1"},
+		{APIVersion: "extensions/v1beta1", Kind: "PodSecurityPolicy", DeprecatedIn: "1.11", RemovedIn: "1.16", Replacement: "policy/v1beta1"},
+		{APIVersion: "apps/v1beta1", Kind: "Deployment", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "apps/v1beta1", Kind: "StatefulSet", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "apps/v1beta2", Kind: "Deployment", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "apps/v1beta2", Kind: "StatefulSet", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "apps/v1beta2", Kind: "DaemonSet", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+		{APIVersion: "apps/v1beta2", Kind: "ReplicaSet", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+
+		// Removed in 1.22
+		{APIVersion: "extensions/v1beta1", Kind: "Ingress", DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+		{APIVersion: "networking.k8s.io/v1beta1", Kind: "Ingress", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+		{APIVersion: "networking.k8s.io/v1beta1", Kind: "IngressClass", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+		{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "admissionregistration.k8s.io/v1"},
+		{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "ValidatingWebhookConfiguration", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "admissionregistration.k8s.io/v1"},
+		{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "apiextensions.k8s.io/v1"},
+		{APIVersion: "apiregistration.k8s.io/v1beta1", Kind: "APIService", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "apiregistration.k8s.io/v1"},
+		{APIVersion: "certificates.k8s.io/v1beta1", Kind: "CertificateSigningRequest", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "certificates.k8s.io/v1"},
+		{APIVersion: "coordination.k8s.io/v1beta1", Kind: "Lease", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "coordination.k8s.io/v1"},
+		{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "ClusterRole", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+		{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "ClusterRoleBinding", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+		{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "Role", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+		{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "RoleBinding", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+		{APIVersion: "scheduling.k8s.io/v1beta1", Kind: "PriorityClass", DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "scheduling.k8s.io/v1"},
+		{APIVersion: "storage.k8s.io/v1beta1", Kind: "CSIDriver", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+		{APIVersion: "storage.k8s.io/v1beta1", Kind: "CSINode", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+		{APIVersion: "storage.k8s.io/v1beta1", Kind: "StorageClass", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+		{APIVersion: "storage.k8s.io/v1beta1", Kind: "VolumeAttachment", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+
+		// Removed in 1.25
+		{APIVersion: "batch/v1beta1", Kind: "CronJob", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "batch/v1"},
+		{APIVersion: "discovery.k8s.io/v1beta1", Kind: "EndpointSlice", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "discovery.k8s.io/v1"},
+		{APIVersion: "events.k8s.io/v1beta1", Kind: "Event", DeprecatedIn: "1.19", RemovedIn: "1.25", Replacement: "events.k8s.io/v1"},
+		{APIVersion: "autoscaling/v2beta1", Kind: "HorizontalPodAutoscaler", DeprecatedIn: "1.23", RemovedIn: "1.25", Replacement: "autoscaling/v2"},
+		{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "policy/v1"},
+		{APIVersion: "policy/v1beta1", Kind: "PodSecurityPolicy", DeprecatedIn: "1.21", RemovedIn: "1.25"},
+		{APIVersion: "node.k8s.io/v1beta1", Kind: "RuntimeClass", DeprecatedIn: "1.20", RemovedIn: "1.25", Replacement: "node.k8s.io/v1"},
+
+		// Removed in 1.26
+		{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta1", Kind: "FlowSchema", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "flowcontrol.apiserver.k8s.io/v1beta3"},
+		{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta1", Kind: "PriorityLevelConfiguration", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "flowcontrol.apiserver.k8s.io/v1beta3"},
+		{APIVersion: "autoscaling/v2beta2", Kind: "HorizontalPodAutoscaler", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "autoscaling/v2"},
+
+		// Removed in 1.27
+		{APIVersion: "storage.k8s.io/v1beta1", Kind: "CSIStorageCapacity", DeprecatedIn: "1.24", RemovedIn: "1.27", Replacement: "storage.k8s.io/v1"},
+
+		// Removed in 1.29
+		{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta2", Kind: "FlowSchema", DeprecatedIn: "1.26", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+		{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta2", Kind: "PriorityLevelConfiguration", DeprecatedIn: "1.26", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+
+		// Removed in 1.32
+		{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3", Kind: "FlowSchema", DeprecatedIn: "1.29", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+		{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3", Kind: "PriorityLevelConfiguration", DeprecatedIn: "1.29", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	}
+)
+
+// API version of kind deprecated in DeprecatedIn and removed in RemovedIn
+type DeprecatedAPI struct {
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	DeprecatedIn string `json:"deprecatedIn"`
+	RemovedIn    string `json:"removedIn"`
+	Replacement  string `json:"replacement,omitempty"`
+}
+
+// Check whether the API is removed in the kubernetes version
+func (d DeprecatedAPI) IsRemovedIn(kubernetesVersion string) bool {
+	return IsVersionAtLeast(kubernetesVersion, d.RemovedIn)
+}
+
+// Object using deprecated API
+type DeprecatedAPIUsage struct {
+	DeprecatedAPI
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Removed   bool     `json:"removed"`
+	Sources   []string `json:"sources"`
+	Warning   string   `json:"warning,omitempty"`
+}
+
+// Result of scanning deprecated APIs
+type DeprecatedAPIScanResult struct {
+	ServerVersion string               `json:"serverVersion"`
+	TargetVersion string               `json:"targetVersion"`
+	Usages        []DeprecatedAPIUsage `json:"usages"`
+	Errors        []string             `json:"errors"`
+}
+
+// Check whether kubernetes version is the same or later than minimum version.
+// Versions like v1.27.4-eks-2d98532 are compared with major and minor versions
+func IsVersionAtLeast(kubernetesVersion, minimum string) bool {
+	current, err := version.ParseGeneric(kubernetesVersion)
+	if err != nil {
+		return false
+	}
+
+	return current.AtLeast(version.MustParseGeneric(minimum))
+}
+
+// Get APIs which are already deprecated in the kubernetes version
+func GetDeprecatedAPIs(kubernetesVersion string) []DeprecatedAPI {
+	ret := []DeprecatedAPI{}
+	for _, api := range DEPRECATED_APIS {
+		if IsVersionAtLeast(kubernetesVersion, api.DeprecatedIn) {
+			ret = append(ret, api)
+		}
+	}
+
+	return ret
+}
+
+// Find deprecated API of the apiVersion and kind
+func findDeprecatedAPI(apis []DeprecatedAPI, apiVersion, kind string) (DeprecatedAPI, bool) {
+	for _, api := range apis {
+		if api.APIVersion == apiVersion && api.Kind == kind {
+			return api, true
+		}
+	}
+
+	return DeprecatedAPI{}, false
+}
+
+// Find live objects whose manifest or managers use APIs deprecated in the kubernetes version.
+// Objects are read with the version served by the cluster, so apiVersion which clients used
+// is found from last-applied-configuration annotation and managed fields
+func FindDeprecatedAPIUsages(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, kubernetesVersion string) ([]DeprecatedAPIUsage, []error) {
+	apis := GetDeprecatedAPIs(kubernetesVersion)
+
+	// Every kind is scanned once with the first group which is served
+	kinds := map[string][]schema.GroupKind{}
+	kindOrder := []string{}
+	for _, api := range apis {
+		groupKinds := []schema.GroupKind{}
+		if len(api.Replacement) > 0 {
+			groupKinds = append(groupKinds, schema.FromAPIVersionAndKind(api.Replacement, api.Kind).GroupKind())
+		}
+		groupKinds = append(groupKinds, schema.FromAPIVersionAndKind(api.APIVersion, api.Kind).GroupKind())
+
+		if _, ok := kinds[api.Kind]; !ok {
+			kindOrder = append(kindOrder, api.Kind)
+		}
+		kinds[api.Kind] = append(kinds[api.Kind], groupKinds...)
+	}
+
+	ret := []DeprecatedAPIUsage{}
+	errs := []error{}
+	for _, kind := range kindOrder {
+		for _, groupKind := range kinds[kind] {
+			mapping, err := mapper.RESTMapping(groupKind)
+			if meta.IsNoMatchError(err) {
+				// The group is not served in the cluster
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot find API of %s: %s", groupKind.String(), err.Error()))
+				break
+			}
+
+			options := metav1.ListOptions{Limit: DEPRECATION_SCAN_PAGE_SIZE}
+			for {
+				list, err := client.Resource(mapping.Resource).List(ctx, options)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("cannot list %s: %s", mapping.Resource.String(), err.Error()))
+					break
+				}
+
+				for _, item := range list.Items {
+					ret = append(ret, findDeprecatedAPIsOfObject(apis, kubernetesVersion, item)...)
+				}
+
+				options.Continue = list.GetContinue()
+				if len(options.Continue) == 0 {
+					break
+				}
+			}
+
+			// Objects are the same in every served group
+			break
+		}
+	}
+
+	sortDeprecatedAPIUsages(ret)
+
+	return ret, errs
+}
+
+// Find objects using deprecated APIs in live objects and manifests of helm releases
+func ScanDeprecatedAPIUsages(ctx context.Context, clientset kubernetes.Interface, client dynamic.Interface, mapper meta.RESTMapper, kubernetesVersion string) ([]DeprecatedAPIUsage, []error) {
+	usages, errs := FindDeprecatedAPIUsages(ctx, client, mapper, kubernetesVersion)
+
+	helmUsages, helmErrs := FindHelmDeprecatedAPIUsages(ctx, clientset, kubernetesVersion)
+	usages = append(usages, helmUsages...)
+	errs = append(errs, helmErrs...)
+
+	sortDeprecatedAPIUsages(usages)
+
+	return usages, errs
+}
+
+// Find deprecated APIs in last applied manifest and managed fields of the object
+func findDeprecatedAPIsOfObject(apis []DeprecatedAPI, kubernetesVersion string, item unstructured.Unstructured) []DeprecatedAPIUsage {
+	kind := item.GetKind()
+	usages := map[string]*DeprecatedAPIUsage{}
+	order := []string{}
+
+	addUsage := func(apiVersion, source string) {
+		api, ok := findDeprecatedAPI(apis, apiVersion, kind)
+		if !ok {
+			return
+		}
+
+		usage, ok := usages[apiVersion]
+		if !ok {
+			usage = &DeprecatedAPIUsage{
+				DeprecatedAPI: api,
+				Namespace:     item.GetNamespace(),
+				Name:          item.GetName(),
+				Sources:       []string{},
+			}
+			usages[apiVersion] = usage
+			order = append(order, apiVersion)
+		}
+
+		for _, existing := range usage.Sources {
+			if existing == source {
+				return
+			}
+		}
+		usage.Sources = append(usage.Sources, source)
+	}
+
+	if lastApplied, ok := item.GetAnnotations()[LAST_APPLIED_ANNOTATION]; ok {
+		var manifest metav1.TypeMeta
+		if err := json.Unmarshal([]byte(lastApplied), &manifest); err == nil {
+			addUsage(manifest.APIVersion, DEPRECATION_SOURCE_LAST_APPLIED)
+		}
+	}
+
+	for _, field := range item.GetManagedFields() {
+		addUsage(field.APIVersion, fmt.Sprintf("%s(%s)", DEPRECATION_SOURCE_MANAGED_FIELD, field.Manager))
+	}
+
+	// Removed API only in managed fields is not counted as removed, because it could be stale
+	ret := []DeprecatedAPIUsage{}
+	for _, apiVersion := range order {
+		usage := usages[apiVersion]
+		if usage.IsRemovedIn(kubernetesVersion) {
+			if hasManifestSource(usage.Sources) {
+				usage.Removed = true
+			} else {
+				usage.Warning = DEPRECATION_WARNING_MANAGED_FIELDS
+			}
+		}
+		ret = append(ret, *usage)
+	}
+
+	return ret
+}
+
+// Check whether manifest of clients uses the API, not only managed fields
+func hasManifestSource(sources []string) bool {
+	for _, source := range sources {
+		if source == DEPRECATION_SOURCE_LAST_APPLIED {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sort usages with removed ones first
+func sortDeprecatedAPIUsages(usages []DeprecatedAPIUsage) {
+	sort.SliceStable(usages, func(i, j int) bool {
+		a, b := usages[i], usages[j]
+		if a.Removed != b.Removed {
+			return a.Removed
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// Render objects using deprecated APIs
+func RenderDeprecatedAPIUsageList(usages []DeprecatedAPIUsage) bool {
+	if len(usages) <= 0 {
+		return false
+	}
+
+	table := table.GetTableObject()
+	table.SetHeader([]string{"KIND", "NAMESPACE", "NAME", "API-VERSION", "REPLACEMENT", "REMOVED-IN", "STATUS", "SOURCE"})
+
+	for _, usage := range usages {
+		status := "Deprecated"
+		if usage.Removed {
+			status = "Removed"
+		} else if len(usage.Warning) > 0 {
+			status = "Warning"
+		}
+
+		replacement := usage.Replacement
+		if len(replacement) == 0 {
+			replacement = "-"
+		}
+
+		table.Append([]string{usage.Kind, usage.Namespace, usage.Name, usage.APIVersion, replacement, usage.RemovedIn, status, strings.Join(usage.Sources, ",")})
+	}
+	table.Render()
+
+	return true
+}
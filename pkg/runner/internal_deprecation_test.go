@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Get deployment applied with the API version and written by managers with API versions
+func newTestDeployment(lastApplied string, managedFields map[string]string) unstructured.Unstructured {
+	item := unstructured.Unstructured{}
+	item.SetAPIVersion("apps/v1")
+	item.SetKind("Deployment")
+	item.SetNamespace("default")
+	item.SetName("web")
+
+	if len(lastApplied) > 0 {
+		item.SetAnnotations(map[string]string{LAST_APPLIED_ANNOTATION: `{"apiVersion":"` + lastApplied + `","kind":"Deployment"}`})
+	}
+
+	fields := []metav1.ManagedFieldsEntry{}
+	for manager, apiVersion := range managedFields {
+		fields = append(fields, metav1.ManagedFieldsEntry{Manager: manager, APIVersion: apiVersion})
+	}
+	item.SetManagedFields(fields)
+
+	return item
+}
+
+func TestFindDeprecatedAPIsOfObject(t *testing.T) {
+	tcs := []struct {
+		name              string
+		item              unstructured.Unstructured
+		kubernetesVersion string
+		expected          []DeprecatedAPIUsage
+	}{
+		{
+			name:              "current API",
+			item:              newTestDeployment("apps/v1", map[string]string{"kubectl": "apps/v1"}),
+			kubernetesVersion: "1.16",
+			expected:          []DeprecatedAPIUsage{},
+		},
+		{
+			name:              "deprecated API not removed yet",
+			item:              newTestDeployment("extensions/v1beta1", nil),
+			kubernetesVersion: "1.15",
+			expected: []DeprecatedAPIUsage{{
+				DeprecatedAPI: DEPRECATED_APIS[0], Namespace: "default", Name: "web",
+				Sources: []string{DEPRECATION_SOURCE_LAST_APPLIED},
+			}},
+		},
+		{
+			name:              "removed API in last applied and managed fields",
+			item:              newTestDeployment("extensions/v1beta1", map[string]string{"kubectl": "extensions/v1beta1"}),
+			kubernetesVersion: "1.16",
+			expected: []DeprecatedAPIUsage{{
+				DeprecatedAPI: DEPRECATED_APIS[0], Namespace: "default", Name: "web", Removed: true,
+				Sources: []string{DEPRECATION_SOURCE_LAST_APPLIED, DEPRECATION_SOURCE_MANAGED_FIELD + "(kubectl)"},
+			}},
+		},
+		{
+			name:              "removed API only in managed fields",
+			item:              newTestDeployment("", map[string]string{"helm": "extensions/v1beta1"}),
+			kubernetesVersion: "1.16",
+			expected: []DeprecatedAPIUsage{{
+				DeprecatedAPI: DEPRECATED_APIS[0], Namespace: "default", Name: "web",
+				Sources: []string{DEPRECATION_SOURCE_MANAGED_FIELD + "(helm)"}, Warning: DEPRECATION_WARNING_MANAGED_FIELDS,
+			}},
+		},
+		{
+			name:              "invalid last applied configuration",
+			item:              newTestDeployment(`"`, nil),
+			kubernetesVersion: "1.16",
+			expected:          []DeprecatedAPIUsage{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			usages := findDeprecatedAPIsOfObject(DEPRECATED_APIS, tc.kubernetesVersion, tc.item)
+			if !reflect.DeepEqual(usages, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, usages)
+			}
+		})
+	}
+}
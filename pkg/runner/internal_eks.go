@@ -1,150 +1,317 @@
 package runner
 
 import (
-	"os"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/AlecAivazis/survey/v2"
-	"github.com/GwonsooLee/kubenx/pkg/utils"
+	kubenxaws "github.com/GwonsooLee/kubenx/pkg/aws"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
-// Get EKS Session
-func GetEksSession() *eks.EKS {
-	awsRegion := viper.GetString("region")
-	mySession := session.Must(session.NewSession())
-	svc := eks.New(mySession, &aws.Config{Region: aws.String(awsRegion)})
+var (
+	// Default number of concurrent AWS requests for discovering clusters
+	DEFAULT_AWS_CONCURRENCY = 8
 
-	return svc
+	// Interval and default timeout of watching update of EKS resources
+	EKS_UPDATE_POLL_INTERVAL = 15 * time.Second
+	EKS_UPDATE_TIMEOUT       = 60 * time.Minute
+)
+
+// EKS cluster found with the role in the region
+type EKSClusterLocation struct {
+	Name    string
+	Region  string
+	Account string
+	Role    string
+
+	// Kubeconfig context of the cluster. It is empty if kubeconfig has no context of the cluster
+	Context string
 }
 
-// Get All EKS Cluster
-func GetEKSClusterList(svc *eks.EKS) []string {
-	if svc == nil {
-		svc = GetEksSession()
+// Get role for AWS sessions. Empty role means current credentials
+func GetRoleReference(role string) *string {
+	if len(role) == 0 {
+		return nil
 	}
 
-	inputParams := &eks.ListClustersInput{MaxResults: aws.Int64(100)}
-	res, err := svc.ListClusters(inputParams)
-	if err != nil {
-		utils.Red(err)
-		os.Exit(1)
+	return &role
+}
+
+// Get name of role shown in messages
+func getRoleLabel(role string) string {
+	if len(role) == 0 {
+		return "current credentials"
 	}
 
-	// Change []*string to []string
-	var ret []string
-	for _, cluster := range res.Clusters {
-		ret = append(ret, *cluster)
+	return role
+}
+
+// Failure of discovering EKS clusters with the role
+type EKSDiscoveryError struct {
+	Action  string
+	Role    string
+	Account string
+	Region  string
+	Cluster string
+	Err     error
+}
+
+func (e *EKSDiscoveryError) Error() string {
+	target := getRoleLabel(e.Role)
+	if len(e.Cluster) > 0 {
+		target = fmt.Sprintf("%s with %s", e.Cluster, target)
+	}
+	if len(e.Region) > 0 {
+		target = fmt.Sprintf("%s in %s", target, e.Region)
 	}
 
-	return ret
+	return fmt.Sprintf("cannot %s of %s: %s", e.Action, target, e.Err.Error())
 }
 
-// Choose cluster
-func ChooseCluster() string {
+// Get the number of concurrent requests from --concurrency flag
+func GetConcurrency() int {
+	if concurrency := viper.GetInt("concurrency"); concurrency > 0 {
+		return concurrency
+	}
 
-	// Check the cluster First
-	var ret string
-	ret = viper.GetString("cluster")
+	return DEFAULT_AWS_CONCURRENCY
+}
 
-	// If cluster is not given then choose!
-	if len(ret) <= 0 {
-		options := GetEKSClusterList(nil)
+// List EKS clusters of all roles in target regions concurrently.
+// Scopes of region and account listed successfully are returned with clusters
+func ListEKSClustersInRegions(roles []string) ([]EKSClusterLocation, map[string]bool, []error) {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
 
-		//Exception if there is no cluster in the account
-		if len(options) == 0 {
-			utils.Yellow("You have no cluster in the account. Please check the account.")
-			os.Exit(0)
+	// Limit requests at the same time not to be throttled with many accounts
+	semaphore := make(chan struct{}, GetConcurrency())
+
+	locations := []EKSClusterLocation{}
+	scanned := map[string]bool{}
+	errs := []error{}
+
+	addError := func(err error) {
+		mutex.Lock()
+		errs = append(errs, err)
+		mutex.Unlock()
+	}
+
+	for _, role := range roles {
+		wg.Add(1)
+		go func(role string) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			account, err := kubenxaws.GetAccountID(kubenxaws.GetSTSSession(GetRoleReference(role)))
+			if err != nil {
+				<-semaphore
+				addError(&EKSDiscoveryError{Action: "get account", Role: role, Err: err})
+				return
+			}
+
+			regions, err := kubenxaws.GetTargetRegions(GetRoleReference(role))
+			<-semaphore
+			if err != nil {
+				addError(&EKSDiscoveryError{Action: "get regions", Role: role, Account: account, Err: err})
+				return
+			}
+
+			for _, region := range regions {
+				wg.Add(1)
+				go func(region string) {
+					defer wg.Done()
+
+					semaphore <- struct{}{}
+					names, err := kubenxaws.ListAllClusters(kubenxaws.GetEksSessionInRegion(GetRoleReference(role), region))
+					<-semaphore
+					if err != nil {
+						addError(&EKSDiscoveryError{Action: "list clusters", Role: role, Account: account, Region: region, Err: err})
+						return
+					}
+
+					mutex.Lock()
+					defer mutex.Unlock()
+					scanned[GetClusterScope(region, account)] = true
+					for _, name := range names {
+						locations = append(locations, EKSClusterLocation{Name: name, Region: region, Account: account, Role: role})
+					}
+				}(region)
+			}
+		}(role)
+	}
+	wg.Wait()
+
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
 		}
-		prompt := &survey.Select{
-			Message: "Choose a cluster:",
-			Options: options,
+		if a.Account != b.Account {
+			return a.Account < b.Account
 		}
-		survey.AskOne(prompt, &ret)
+		return a.Role < b.Role
+	})
 
-		if ret == "" {
-			utils.Red("You canceled the choice")
-			os.Exit(1)
+	// Roles in the same account find the same clusters
+	ret := []EKSClusterLocation{}
+	for i, location := range locations {
+		if i > 0 && location.Region == locations[i-1].Region && location.Account == locations[i-1].Account && location.Name == locations[i-1].Name {
+			continue
 		}
+		ret = append(ret, location)
 	}
 
-	return ret
+	return ret, scanned, errs
 }
 
-// Get Cluster Information with session
-func GetClusterInfoWithSession(svc *eks.EKS, cluster string) *eks.DescribeClusterOutput {
-	inputParamsDesc := &eks.DescribeClusterInput{Name: aws.String(cluster)}
-	ret, err := svc.DescribeCluster(inputParamsDesc)
+// Describe EKS clusters concurrently. Clusters failed to describe are returned as errors
+func DescribeEKSClusters(locations []EKSClusterLocation) ([]EKSClusterConfig, []error) {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	semaphore := make(chan struct{}, GetConcurrency())
 
-	if err != nil {
-		utils.Red(err)
-		os.Exit(1)
+	// Keep the order of locations
+	clusters := make([]*EKSClusterConfig, len(locations))
+	errs := []error{}
+
+	for i, location := range locations {
+		wg.Add(1)
+		go func(i int, location EKSClusterLocation) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			svc := kubenxaws.GetEksSessionInRegion(GetRoleReference(location.Role), location.Region)
+			clusterInfo, err := kubenxaws.GetClusterInfo(svc, location.Name)
+			<-semaphore
+			if err != nil {
+				mutex.Lock()
+				errs = append(errs, &EKSDiscoveryError{Action: "describe cluster", Role: location.Role, Account: location.Account, Region: location.Region, Cluster: location.Name, Err: err})
+				mutex.Unlock()
+				return
+			}
+
+			cluster := NewEKSClusterConfig(clusterInfo.Cluster, location.Region, location.Role)
+			clusters[i] = &cluster
+		}(i, location)
 	}
+	wg.Wait()
 
-	return ret
+	ret := []EKSClusterConfig{}
+	for _, cluster := range clusters {
+		if cluster != nil {
+			ret = append(ret, *cluster)
+		}
+	}
+
+	return ret, errs
 }
 
-// Get Nodegroup List with eks session
-func GetNodeGroupList(svc *eks.EKS, cluster string) []string {
-	if svc == nil {
-		svc = GetEksSession()
+// Get EKS cluster of current context.
+// Cluster written by kubenx has its ARN as name and role in arguments of token command,
+// otherwise context name is regarded as cluster name in the region of --region flag
+func GetCurrentEKSCluster() (EKSClusterLocation, error) {
+	currentConfig, err := GetCurrentConfig()
+	if err != nil {
+		return EKSClusterLocation{}, err
 	}
 
-	inputParams := &eks.ListNodegroupsInput{ClusterName: aws.String(cluster), MaxResults: aws.Int64(100)}
-	res, err := svc.ListNodegroups(inputParams)
+	location, _ := getContextEKSCluster(currentConfig, currentConfig.CurrentContext)
+	return location, nil
+}
+
+// Get EKS cluster with the name given by --cluster flag.
+// Region and role are found from the context of the cluster like the current context,
+// and current context is preferred if several contexts use clusters with the name
+func FindEKSCluster(name string) (EKSClusterLocation, error) {
+	currentConfig, err := GetCurrentConfig()
 	if err != nil {
-		utils.Red(err)
-		os.Exit(1)
+		return EKSClusterLocation{}, err
 	}
 
-	// Change []*string to []string
-	var ret []string
-	for _, ng := range res.Nodegroups {
-		ret = append(ret, *ng)
+	names := []string{}
+	for contextName := range currentConfig.Contexts {
+		if contextName != currentConfig.CurrentContext {
+			names = append(names, contextName)
+		}
 	}
+	sort.Strings(names)
+	names = append([]string{currentConfig.CurrentContext}, names...)
 
-	return ret
+	for _, contextName := range names {
+		location, ok := getContextEKSCluster(currentConfig, contextName)
+		if !ok || location.Name != name {
+			continue
+		}
+
+		// Cluster could have the same name in other regions
+		if viper.IsSet("region") && location.Region != viper.GetString("region") {
+			continue
+		}
+
+		return location, nil
+	}
+
+	return EKSClusterLocation{Name: name, Region: viper.GetString("region")}, nil
 }
 
-// Choose cluster
-func ChooseNodegroup(cluster string) string {
-	var ret string
-	ret = viper.GetString("nodegroup")
+// Get EKS cluster of the context. False is returned if cluster of the context is not EKS cluster ARN
+func getContextEKSCluster(config *api.Config, contextName string) (EKSClusterLocation, bool) {
+	location := EKSClusterLocation{Name: contextName, Region: viper.GetString("region")}
 
-	// If nogegroup is not given then choose!
-	if len(ret) <= 0 {
-		options := GetNodeGroupList(nil, cluster)
+	kubeContext, ok := config.Contexts[contextName]
+	if !ok {
+		return location, false
+	}
+	location.Context = contextName
 
-		//Exception if there is no nodegroup in the cluster
-		if len(options) == 0 {
-			utils.Yellow("You have no nodegroup in the cluster. Please check the cluster.")
-			os.Exit(0)
+	parsed, err := arn.Parse(kubeContext.Cluster)
+	isEKS := err == nil && parsed.Service == "eks" && strings.HasPrefix(parsed.Resource, "cluster/")
+	if isEKS {
+		location.Name = strings.TrimPrefix(parsed.Resource, "cluster/")
+		location.Region = parsed.Region
+		location.Account = parsed.AccountID
+	}
+
+	if authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]; ok && authInfo.Exec != nil {
+		location.Role = getExecArgument(authInfo.Exec.Args, "--role-arn")
+		if len(location.Role) == 0 {
+			location.Role = getExecArgument(authInfo.Exec.Args, "--assume")
 		}
+	}
+
+	return location, isEKS
+}
 
-		prompt := &survey.Select{
-			Message: "Choose a nodegroup:",
-			Options: options,
+// Get value of the argument from arguments of exec configuration
+func getExecArgument(args []string, name string) string {
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
 		}
-		survey.AskOne(prompt, &ret)
 
-		if ret == "" {
-			utils.Red("You canceled the choice")
-			os.Exit(1)
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"=")
 		}
 	}
 
-	return ret
+	return ""
 }
 
-// Get Cluster Information with session
-func GetNodegroupInfoWithSession(svc *eks.EKS, cluster string, nodegroup string) *eks.DescribeNodegroupOutput {
-	ngParams := &eks.DescribeNodegroupInput{ClusterName: aws.String(cluster), NodegroupName: aws.String(nodegroup)}
-	ret, err := svc.DescribeNodegroup(ngParams)
-	if err != nil {
-		utils.Red(err)
-		os.Exit(1)
+// Get errors of failed update
+func GetUpdateErrors(update *eks.Update) []string {
+	ret := []string{}
+	for _, updateError := range update.Errors {
+		ret = append(ret, fmt.Sprintf("%s: %s", aws.StringValue(updateError.ErrorCode), aws.StringValue(updateError.ErrorMessage)))
 	}
 
 	return ret
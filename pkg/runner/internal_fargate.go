@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kubenxaws "github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// Describe all fargate profiles in the cluster
+func GetAllFargateProfiles(svc *eks.EKS, cluster string) ([]*eks.FargateProfile, error) {
+	names, err := kubenxaws.ListAllFargateProfiles(svc, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*eks.FargateProfile{}
+	for _, name := range names {
+		profile, err := kubenxaws.GetFargateProfileInfo(svc, cluster, name)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, profile)
+	}
+
+	return ret, nil
+}
+
+// Render Fargate profile list
+func RenderFargateProfileListInfo(profiles []*eks.FargateProfile) bool {
+	if len(profiles) <= 0 {
+		return false
+	}
+
+	now := time.Now()
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader([]string{"NAME", "STATUS", "SELECTORS", "SUBNETS", "POD-EXECUTION-ROLE", "AGE"})
+
+	for _, profile := range profiles {
+		table.Append([]string{
+			aws.StringValue(profile.FargateProfileName),
+			aws.StringValue(profile.Status),
+			strings.Join(getFargateSelectors(profile), " "),
+			strings.Join(aws.StringValueSlice(profile.Subnets), ","),
+			aws.StringValue(profile.PodExecutionRoleArn),
+			duration.HumanDuration(now.Sub(aws.TimeValue(profile.CreatedAt))),
+		})
+	}
+	table.Render()
+
+	return true
+}
+
+// Get selectors of fargate profile in the format of namespace{label=value,...}
+func getFargateSelectors(profile *eks.FargateProfile) []string {
+	selectors := []string{}
+	for _, selector := range profile.Selectors {
+		labels := []string{}
+		for key, value := range selector.Labels {
+			labels = append(labels, fmt.Sprintf("%s=%s", key, aws.StringValue(value)))
+		}
+		sort.Strings(labels)
+
+		line := aws.StringValue(selector.Namespace)
+		if len(labels) > 0 {
+			line += fmt.Sprintf("{%s}", strings.Join(labels, ","))
+		}
+		selectors = append(selectors, line)
+	}
+
+	return selectors
+}
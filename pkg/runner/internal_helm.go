@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// Secret type of helm 3 release storage
+	HELM_RELEASE_SECRET_TYPE = "helm.sh/release.v1"
+
+	// Only deployed revisions are installed in the cluster
+	HELM_DEPLOYED_RELEASE_SELECTOR = "owner=helm,status=deployed"
+
+	DEPRECATION_SOURCE_HELM = "helm"
+)
+
+// Release stored by helm with rendered manifest
+type HelmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Manifest  string `json:"manifest"`
+}
+
+// Object in rendered manifest of helm release
+type HelmManifestObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// Get deployed helm releases from release secrets in all namespaces
+func GetHelmReleases(ctx context.Context, clientset kubernetes.Interface) ([]HelmRelease, []error) {
+	secrets, err := clientset.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("type=%s", HELM_RELEASE_SECRET_TYPE),
+		LabelSelector: HELM_DEPLOYED_RELEASE_SELECTOR,
+	})
+	if err != nil {
+		return nil, []error{fmt.Errorf("cannot list helm release secrets: %s", err.Error())}
+	}
+
+	ret := []HelmRelease{}
+	errs := []error{}
+	for _, secret := range secrets.Items {
+		release, err := DecodeHelmRelease(secret)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot decode helm release %s/%s: %s", secret.Namespace, secret.Name, err.Error()))
+			continue
+		}
+		ret = append(ret, release)
+	}
+
+	return ret, errs
+}
+
+// Decode release in helm secret, which is base64 encoded gzip of release json
+func DecodeHelmRelease(secret corev1.Secret) (HelmRelease, error) {
+	data, err := base64.StdEncoding.DecodeString(string(secret.Data["release"]))
+	if err != nil {
+		return HelmRelease{}, err
+	}
+
+	// Helm compresses release with gzip, but old releases could be plain json
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return HelmRelease{}, err
+		}
+		defer reader.Close()
+
+		data, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return HelmRelease{}, err
+		}
+	}
+
+	var release HelmRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return HelmRelease{}, err
+	}
+
+	return release, nil
+}
+
+// Get objects in rendered manifest of helm release
+func GetHelmManifestObjects(release HelmRelease) ([]HelmManifestObject, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(release.Manifest)), 4096)
+
+	ret := []HelmManifestObject{}
+	for {
+		var object HelmManifestObject
+		if err := decoder.Decode(&object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		// Empty documents are decoded without kind
+		if len(object.Kind) == 0 {
+			continue
+		}
+
+		if len(object.Metadata.Namespace) == 0 {
+			object.Metadata.Namespace = release.Namespace
+		}
+		ret = append(ret, object)
+	}
+
+	return ret, nil
+}
+
+// Find objects of helm releases using APIs deprecated in the kubernetes version.
+// Helm fails to upgrade release if its previous manifest has API removed in the cluster
+func FindHelmDeprecatedAPIUsages(ctx context.Context, clientset kubernetes.Interface, kubernetesVersion string) ([]DeprecatedAPIUsage, []error) {
+	apis := GetDeprecatedAPIs(kubernetesVersion)
+
+	releases, errs := GetHelmReleases(ctx, clientset)
+
+	ret := []DeprecatedAPIUsage{}
+	for _, release := range releases {
+		objects, err := GetHelmManifestObjects(release)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot parse manifest of helm release %s/%s: %s", release.Namespace, release.Name, err.Error()))
+			continue
+		}
+
+		for _, object := range objects {
+			api, ok := findDeprecatedAPI(apis, object.APIVersion, object.Kind)
+			if !ok {
+				continue
+			}
+
+			ret = append(ret, DeprecatedAPIUsage{
+				DeprecatedAPI: api,
+				Namespace:     object.Metadata.Namespace,
+				Name:          object.Metadata.Name,
+				Removed:       api.IsRemovedIn(kubernetesVersion),
+				Sources:       []string{fmt.Sprintf("%s(%s)", DEPRECATION_SOURCE_HELM, release.Name)},
+			})
+		}
+	}
+
+	sortDeprecatedAPIUsages(ret)
+
+	return ret, errs
+}
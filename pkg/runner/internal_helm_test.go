@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Get helm release secret with the release data encoded like helm
+func newTestHelmSecret(raw []byte, compress bool) corev1.Secret {
+	if compress {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		writer.Write(raw)
+		writer.Close()
+		raw = buf.Bytes()
+	}
+
+	return corev1.Secret{Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(raw))}}
+}
+
+func TestDecodeHelmRelease(t *testing.T) {
+	raw := []byte(`{"name":"web","namespace":"default","version":3,"manifest":"apiVersion: apps/v1\nkind: Deployment\n"}`)
+	expected := HelmRelease{Name: "web", Namespace: "default", Version: 3, Manifest: "apiVersion: apps/v1\nkind: Deployment\n"}
+
+	tcs := []struct {
+		name      string
+		secret    corev1.Secret
+		expectErr bool
+	}{
+		{name: "gzip release", secret: newTestHelmSecret(raw, true)},
+		{name: "plain json release", secret: newTestHelmSecret(raw, false)},
+		{name: "invalid base64", secret: corev1.Secret{Data: map[string][]byte{"release": []byte("!!!")}}, expectErr: true},
+		{name: "invalid json", secret: newTestHelmSecret([]byte("{"), true), expectErr: true},
+		{name: "broken gzip", secret: newTestHelmSecret([]byte{0x1f, 0x8b, 0x00}, false), expectErr: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			release, err := DecodeHelmRelease(tc.secret)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", release)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if release != expected {
+				t.Errorf("expected %+v, got %+v", expected, release)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/GwonsooLee/kubenx/pkg/table"
+)
+
+// Container running the matched image
+type ImageUsage struct {
+	Context   string
+	Namespace string
+	Pod       string
+	Owner     string
+	Container string
+	Image     string
+	Digest    string
+}
+
+// Matcher for image with substring or glob pattern
+type ImageMatcher struct {
+	pattern string
+	glob    *regexp.Regexp
+}
+
+// Create image matcher. Pattern with "*" or "?" is treated as glob
+func NewImageMatcher(pattern string) (*ImageMatcher, error) {
+	matcher := &ImageMatcher{pattern: pattern}
+	if !strings.ContainsAny(pattern, "*?") {
+		return matcher, nil
+	}
+
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+
+	glob, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+	matcher.glob = glob
+
+	return matcher, nil
+}
+
+// Check whether the image matches the pattern
+func (m *ImageMatcher) Match(image string) bool {
+	if m.glob != nil {
+		return m.glob.MatchString(image)
+	}
+	return strings.Contains(image, m.pattern)
+}
+
+// Get rest config of the context in kubeconfig
+func GetConfigForContext(rawConfig api.Config, contextName string) (*rest.Config, error) {
+	return clientcmd.NewNonInteractiveClientConfig(rawConfig, contextName, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+}
+
+// Find containers of pods running the matched image
+func FindImageUsages(ctx context.Context, clientset *kubernetes.Clientset, contextName, namespace string, matcher *ImageMatcher) ([]ImageUsage, error) {
+	pods, err := GetAllRawPods(ctx, clientset, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []ImageUsage{}
+	owners := map[string]string{}
+	for _, pod := range pods {
+		digests := map[string]string{}
+		for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+			digests[status.Name] = getImageDigest(status.ImageID)
+		}
+
+		for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			if !matcher.Match(container.Image) {
+				continue
+			}
+
+			ret = append(ret, ImageUsage{
+				Context:   contextName,
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				Owner:     getTopLevelOwner(ctx, clientset, pod.Namespace, pod.OwnerReferences, owners),
+				Container: container.Name,
+				Image:     container.Image,
+				Digest:    digests[container.Name],
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// Get digest from image ID like docker-pullable://nginx@sha256:...
+func getImageDigest(imageID string) string {
+	if idx := strings.LastIndex(imageID, "@"); idx >= 0 {
+		return imageID[idx+1:]
+	}
+	return imageID
+}
+
+// Resolve owner of pod to workload. ReplicaSet is resolved to Deployment and Job to CronJob
+func getTopLevelOwner(ctx context.Context, clientset *kubernetes.Clientset, namespace string, references []metav1.OwnerReference, cache map[string]string) string {
+	reference := metav1.GetControllerOfNoCopy(&metav1.ObjectMeta{OwnerReferences: references})
+	if reference == nil {
+		return "-"
+	}
+
+	key := namespace + "/" + reference.Kind + "/" + reference.Name
+	if owner, ok := cache[key]; ok {
+		return owner
+	}
+
+	var parents []metav1.OwnerReference
+	switch reference.Kind {
+	case "ReplicaSet":
+		replicaSet, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, reference.Name, metav1.GetOptions{})
+		if err == nil {
+			parents = replicaSet.OwnerReferences
+		}
+	case "Job":
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, reference.Name, metav1.GetOptions{})
+		if err == nil {
+			parents = job.OwnerReferences
+		}
+	}
+
+	owner := reference.Kind + "/" + reference.Name
+	if parent := metav1.GetControllerOfNoCopy(&metav1.ObjectMeta{OwnerReferences: parents}); parent != nil {
+		owner = parent.Kind + "/" + parent.Name
+	}
+	cache[key] = owner
+
+	return owner
+}
+
+// Render containers running the matched image
+func RenderImageUsageList(usages []ImageUsage) bool {
+	if len(usages) <= 0 {
+		return false
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Context != usages[j].Context {
+			return usages[i].Context < usages[j].Context
+		}
+		if usages[i].Namespace != usages[j].Namespace {
+			return usages[i].Namespace < usages[j].Namespace
+		}
+		return usages[i].Pod < usages[j].Pod
+	})
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader([]string{"CONTEXT", "NAMESPACE", "POD", "OWNER", "CONTAINER", "IMAGE", "DIGEST"})
+
+	for _, usage := range usages {
+		table.Append([]string{usage.Context, usage.Namespace, usage.Pod, usage.Owner, usage.Container, usage.Image, usage.Digest})
+	}
+	table.Render()
+
+	return true
+}
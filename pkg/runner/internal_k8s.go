@@ -2,18 +2,17 @@ package runner
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -50,68 +49,77 @@ type PortForwardAPodRequest struct {
 	ReadyCh chan struct{}
 }
 
+// Kubeconfig file and context given by root flags.
+// These are not in viper, because automatic env would read KUBECONFIG list as a file path
+var (
+	KubeconfigPath string
+	ContextName    string
+)
+
+// Get client configuration from kubeconfig files with --kubeconfig, --context and --namespace flags.
+// Every command should load kubeconfig with this, so KUBECONFIG list is merged in the same way
+func GetClientConfig() clientcmd.ClientConfig {
+	return GetClientConfigOfContext(ContextName)
+}
+
+// Get client configuration of the context from the same kubeconfig files. Empty name means current context
+func GetClientConfigOfContext(contextName string) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = KubeconfigPath
+
+	configOverrides := &clientcmd.ConfigOverrides{
+		CurrentContext: contextName,
+		Context:        api.Context{Namespace: viper.GetString("namespace")},
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+}
+
+// Get access to kubeconfig files for modifying them
+func GetConfigAccess() *clientcmd.PathOptions {
+	configAccess := clientcmd.NewDefaultPathOptions()
+	configAccess.LoadingRules.ExplicitPath = KubeconfigPath
+
+	return configAccess
+}
+
 // Get Current Cluster
 func GetCurrentCluster() (string, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	rawConfig, err := clientcmd.ClientConfig.RawConfig(kubeConfig)
+	currentConfig, err := GetCurrentConfig()
 	if err != nil {
 		return utils.NO_STRING, err
 	}
 
-	return rawConfig.CurrentContext, nil
+	return currentConfig.CurrentContext, nil
 }
 
-// Get api configuration
-func GetAPIConfig() (*api.Config, *string, error) {
-	var kubeconfig *string
-	if home := utils.HomeDir(); home != "" {
-		kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
-	} else {
-		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-	}
-	flag.Parse()
-
-	configs, err := clientcmd.LoadFromFile(*kubeconfig)
+// Get api configuration written in kubeconfig files without overrides of flags
+func GetAPIConfig() (*api.Config, clientcmd.ConfigAccess, error) {
+	configAccess := GetConfigAccess()
+	configs, err := configAccess.GetStartingConfig()
 	if err != nil {
-		return nil, kubeconfig, err
+		return nil, configAccess, err
 	}
 
-	return configs, kubeconfig, nil
+	return configs, configAccess, nil
 }
 
 //Get current configuration
 func GetCurrentConfig() (*api.Config, error) {
-	configOverrides := &clientcmd.ConfigOverrides{}
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	currentConfig, err := kubeConfig.RawConfig()
+	currentConfig, err := GetClientConfig().RawConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return &currentConfig, nil
-}
-
-func GetConfigFromFlag() (*rest.Config, error) {
-	//Get kubernetes Client
-	var kubeconfig *string
-	if home := utils.HomeDir(); home != "" {
-		kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
-	} else {
-		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-	}
-
-	flag.Parse()
-
-	// use the current context in kubeconfig
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
-	if err != nil {
-		return nil, err
+	// Raw configuration does not have override of current context
+	if len(ContextName) > 0 {
+		if _, ok := currentConfig.Contexts[ContextName]; !ok {
+			return nil, fmt.Errorf("context %s doesn't exist in kubeconfig", ContextName)
+		}
+		currentConfig.CurrentContext = ContextName
 	}
 
-	return config, nil
+	return &currentConfig, nil
 }
 
 // Get All Raw Pod list
@@ -599,9 +607,23 @@ func RenderPodListInfo(pods []corev1.Pod) bool {
 		return false
 	}
 
+	// Pods on Fargate show the profile which matched them
+	hasFargatePod := false
+	for _, pod := range pods {
+		if _, ok := pod.Labels[utils.FARGATE_LABEL]; ok {
+			hasFargatePod = true
+			break
+		}
+	}
+
+	header := []string{"Name", "READY", "STATUS", "Hostname", "Pod IP", "Host IP", "Node", "Age"}
+	if hasFargatePod {
+		header = append(header, "Fargate Profile")
+	}
+
 	// Table setup
 	table := table.GetTableObject()
-	table.SetHeader(combineNamespace([]string{"Name", "READY", "STATUS", "Hostname", "Pod IP", "Host IP", "Node", "Age"}, true, namespace, utils.NO_STRING))
+	table.SetHeader(combineNamespace(header, true, namespace, utils.NO_STRING))
 
 	now := time.Now()
 	for _, pod := range pods {
@@ -635,7 +657,12 @@ func RenderPodListInfo(pods []corev1.Pod) bool {
 			}
 		}
 
-		table.Append(combineNamespace([]string{objectMeta.Name, strconv.Itoa(readyCount) + "/" + strconv.Itoa(totalCount), status, podSpec.Hostname, podStatus.PodIP, podStatus.HostIP, podSpec.NodeName, duration}, false, namespace, objectMeta.Namespace))
+		row := []string{objectMeta.Name, strconv.Itoa(readyCount) + "/" + strconv.Itoa(totalCount), status, podSpec.Hostname, podStatus.PodIP, podStatus.HostIP, podSpec.NodeName, duration}
+		if hasFargatePod {
+			row = append(row, objectMeta.Labels[utils.FARGATE_LABEL])
+		}
+
+		table.Append(combineNamespace(row, false, namespace, objectMeta.Namespace))
 	}
 	table.Render()
 
@@ -722,19 +749,11 @@ func RenderNodeListInfo(nodes []corev1.Node) bool {
 func GetNamespace() (string, error) {
 	//Check the flag
 	setAll := viper.GetBool("all")
-	namespace := viper.GetString("namespace")
-
-	// If no flag is given, then set current namespace
-	if len(namespace) <= 0 {
-		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-		configOverrides := &clientcmd.ConfigOverrides{}
-		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-		current, _, err := clientcmd.ClientConfig.Namespace(kubeConfig)
-		if err != nil {
-			return utils.NO_STRING, err
-		}
 
-		namespace = current
+	// Namespace flag overrides current namespace
+	namespace, _, err := GetClientConfig().Namespace()
+	if err != nil {
+		return utils.NO_STRING, err
 	}
 
 	if setAll && namespace != utils.NO_STRING {
@@ -744,17 +763,72 @@ func GetNamespace() (string, error) {
 	return namespace, nil
 }
 
-// Get Current Cluster
-func getCurrentCluster() string {
+// CA bundle of admission webhook
+type WebhookCABundle struct {
+	Kind     string
+	Name     string
+	CABundle []byte
+}
 
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	rawConfig, err := clientcmd.ClientConfig.RawConfig(kubeConfig)
+// Get CA bundles of all mutating and validating webhooks
+func GetAllWebhookCABundles(ctx context.Context, clientset *kubernetes.Clientset) ([]WebhookCABundle, error) {
+	ret := []WebhookCABundle{}
+
+	mutatings, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		utils.Red(err.Error())
-		os.Exit(1)
+		// admissionregistration/v1 is not served before kubernetes 1.16.
+		// Other errors like forbidden are returned as they are
+		if apierrors.IsNotFound(err) {
+			return getAllWebhookCABundlesV1beta1(ctx, clientset)
+		}
+		return nil, err
+	}
+
+	for _, config := range mutatings.Items {
+		for _, webhook := range config.Webhooks {
+			ret = append(ret, WebhookCABundle{Kind: "MutatingWebhook", Name: config.Name + "/" + webhook.Name, CABundle: webhook.ClientConfig.CABundle})
+		}
+	}
+
+	validatings, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range validatings.Items {
+		for _, webhook := range config.Webhooks {
+			ret = append(ret, WebhookCABundle{Kind: "ValidatingWebhook", Name: config.Name + "/" + webhook.Name, CABundle: webhook.ClientConfig.CABundle})
+		}
+	}
+
+	return ret, nil
+}
+
+// Get CA bundles of webhooks with admissionregistration/v1beta1
+func getAllWebhookCABundlesV1beta1(ctx context.Context, clientset *kubernetes.Clientset) ([]WebhookCABundle, error) {
+	ret := []WebhookCABundle{}
+
+	mutatings, err := clientset.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range mutatings.Items {
+		for _, webhook := range config.Webhooks {
+			ret = append(ret, WebhookCABundle{Kind: "MutatingWebhook", Name: config.Name + "/" + webhook.Name, CABundle: webhook.ClientConfig.CABundle})
+		}
+	}
+
+	validatings, err := clientset.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range validatings.Items {
+		for _, webhook := range config.Webhooks {
+			ret = append(ret, WebhookCABundle{Kind: "ValidatingWebhook", Name: config.Name + "/" + webhook.Name, CABundle: webhook.ClientConfig.CABundle})
+		}
 	}
 
-	return rawConfig.CurrentContext
+	return ret, nil
 }
@@ -0,0 +1,405 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	kubenxaws "github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/eks"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// Taint effects of EKS API in the format of kubernetes
+	NODEGROUP_TAINT_EFFECTS = map[string]string{
+		eks.TaintEffectNoSchedule:       "NoSchedule",
+		eks.TaintEffectNoExecute:        "NoExecute",
+		eks.TaintEffectPreferNoSchedule: "PreferNoSchedule",
+	}
+
+	// Label which EKS sets on nodes of managed nodegroup
+	NODEGROUP_LABEL = "eks.amazonaws.com/nodegroup"
+
+	// Size of scaling configuration which is not given
+	NODEGROUP_SIZE_UNSET int64 = -1
+
+	NODE_CHANGE_JOINED  = "joined"
+	NODE_CHANGE_LEFT    = "left"
+	NODE_CHANGE_UPDATED = "updated"
+)
+
+// Change of node in nodegroup between two observations
+type NodeChange struct {
+	Action string
+	Node   string
+	Status string
+}
+
+// Get Nodegroup for inspect
+func GetTargetNodegroup(svc *eks.EKS, cluster string, args []string) (string, error) {
+	// Pass from command
+	if len(args) == 1 {
+		return args[0], nil
+	}
+
+	options, err := kubenxaws.ListAllNodegroups(svc, cluster)
+	if err != nil {
+		return utils.NO_STRING, err
+	}
+
+	if len(options) == 0 {
+		return utils.NO_STRING, fmt.Errorf("No nodegroup exists in %s", cluster)
+	}
+
+	var nodegroup string
+	prompt := &survey.Select{
+		Message: "Choose a nodegroup:",
+		Options: options,
+	}
+	survey.AskOne(prompt, &nodegroup)
+
+	if len(nodegroup) == 0 {
+		return utils.NO_STRING, fmt.Errorf("No nodegroup is selected")
+	}
+
+	return nodegroup, nil
+}
+
+// Describe all nodegroups in the cluster
+func GetAllNodegroups(svc *eks.EKS, cluster string) ([]*eks.Nodegroup, error) {
+	names, err := kubenxaws.ListAllNodegroups(svc, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*eks.Nodegroup{}
+	for _, name := range names {
+		nodegroup, err := kubenxaws.GetNodegroupInfo(svc, cluster, name)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, nodegroup)
+	}
+
+	return ret, nil
+}
+
+// Describe autoscaling groups of the nodegroup
+func GetNodegroupAutoscalingGroups(svc *autoscaling.AutoScaling, nodegroup *eks.Nodegroup) ([]*autoscaling.Group, error) {
+	names := []*string{}
+	if nodegroup.Resources != nil {
+		for _, group := range nodegroup.Resources.AutoScalingGroups {
+			names = append(names, group.Name)
+		}
+	}
+
+	return kubenxaws.GetAutoscalingGroupsInfo(svc, names)
+}
+
+// Render Nodegroup list
+func RenderNodegroupListInfo(nodegroups []*eks.Nodegroup) bool {
+	if len(nodegroups) <= 0 {
+		return false
+	}
+
+	now := time.Now()
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader([]string{"NAME", "STATUS", "INSTANCE-TYPES", "CAPACITY", "MIN", "DESIRED", "MAX", "AMI-TYPE", "RELEASE-VERSION", "VERSION", "AGE"})
+
+	for _, nodegroup := range nodegroups {
+		min, desired, max := getNodegroupScaling(nodegroup)
+
+		table.Append([]string{
+			aws.StringValue(nodegroup.NodegroupName),
+			aws.StringValue(nodegroup.Status),
+			strings.Join(aws.StringValueSlice(nodegroup.InstanceTypes), ","),
+			aws.StringValue(nodegroup.CapacityType),
+			min,
+			desired,
+			max,
+			aws.StringValue(nodegroup.AmiType),
+			aws.StringValue(nodegroup.ReleaseVersion),
+			aws.StringValue(nodegroup.Version),
+			duration.HumanDuration(now.Sub(aws.TimeValue(nodegroup.CreatedAt))),
+		})
+	}
+	table.Render()
+
+	return true
+}
+
+// Render detail of nodegroup
+func RenderNodegroupDetail(nodegroup *eks.Nodegroup) {
+	table := table.GetTableObject()
+	table.SetHeader([]string{"NAME", aws.StringValue(nodegroup.NodegroupName)})
+	table.Append([]string{"Status", aws.StringValue(nodegroup.Status)})
+	table.Append([]string{"Version", aws.StringValue(nodegroup.Version)})
+	table.Append([]string{"AMI Type", aws.StringValue(nodegroup.AmiType)})
+	table.Append([]string{"Release Version", aws.StringValue(nodegroup.ReleaseVersion)})
+	table.Append([]string{"Capacity Type", aws.StringValue(nodegroup.CapacityType)})
+	table.Append([]string{"Instance Types", strings.Join(aws.StringValueSlice(nodegroup.InstanceTypes), ",")})
+	table.Append([]string{"Disk Size", getNodegroupDiskSize(nodegroup)})
+	table.Append([]string{"Scaling(min/desired/max)", GetNodegroupScalingLabel(nodegroup.ScalingConfig)})
+	table.Append([]string{"Labels", strings.Join(getNodegroupLabels(nodegroup), ",")})
+	table.Append([]string{"Taints", strings.Join(getNodegroupTaints(nodegroup), ",")})
+	table.Append([]string{"Launch Template", getNodegroupLaunchTemplate(nodegroup)})
+	table.Append([]string{"Node Role", aws.StringValue(nodegroup.NodeRole)})
+	table.Append([]string{"Subnets", strings.Join(aws.StringValueSlice(nodegroup.Subnets), ",")})
+
+	if nodegroup.Health != nil {
+		for _, issue := range nodegroup.Health.Issues {
+			table.Append([]string{"Health Issue", fmt.Sprintf("%s: %s", aws.StringValue(issue.Code), aws.StringValue(issue.Message))})
+		}
+	}
+	table.Render()
+}
+
+// Render instances of autoscaling groups
+func RenderAutoscalingInstances(groups []*autoscaling.Group) bool {
+	instances := 0
+	for _, group := range groups {
+		instances += len(group.Instances)
+	}
+
+	if instances <= 0 {
+		return false
+	}
+
+	table := table.GetTableObject()
+	table.SetHeader([]string{"AUTOSCALING-GROUP", "INSTANCE-ID", "LIFECYCLE", "HEALTH", "INSTANCE-TYPE", "AVAILABILITY-ZONE"})
+
+	for _, group := range groups {
+		for _, instance := range group.Instances {
+			table.Append([]string{
+				aws.StringValue(group.AutoScalingGroupName),
+				aws.StringValue(instance.InstanceId),
+				aws.StringValue(instance.LifecycleState),
+				aws.StringValue(instance.HealthStatus),
+				aws.StringValue(instance.InstanceType),
+				aws.StringValue(instance.AvailabilityZone),
+			})
+		}
+	}
+	table.Render()
+
+	return true
+}
+
+// Render EKS cluster list
+func RenderClusterListInfo(clusters []*eks.Cluster) bool {
+	if len(clusters) <= 0 {
+		return false
+	}
+
+	now := time.Now()
+
+	table := table.GetTableObject()
+	table.SetHeader([]string{"NAME", "STATUS", "VERSION", "PLATFORM-VERSION", "ARN", "AGE"})
+
+	for _, cluster := range clusters {
+		table.Append([]string{
+			aws.StringValue(cluster.Name),
+			aws.StringValue(cluster.Status),
+			aws.StringValue(cluster.Version),
+			aws.StringValue(cluster.PlatformVersion),
+			aws.StringValue(cluster.Arn),
+			duration.HumanDuration(now.Sub(aws.TimeValue(cluster.CreatedAt))),
+		})
+	}
+	table.Render()
+
+	return true
+}
+
+// Get scaling configuration in the format of min/desired/max
+func GetNodegroupScalingLabel(scaling *eks.NodegroupScalingConfig) string {
+	if scaling == nil {
+		return utils.NO_STRING
+	}
+
+	return fmt.Sprintf("%d/%d/%d", aws.Int64Value(scaling.MinSize), aws.Int64Value(scaling.DesiredSize), aws.Int64Value(scaling.MaxSize))
+}
+
+// Get kubernetes version of nodegroup with AMI release version
+func GetNodegroupVersionLabel(nodegroup *eks.Nodegroup) string {
+	if nodegroup.ReleaseVersion == nil {
+		return aws.StringValue(nodegroup.Version)
+	}
+
+	return fmt.Sprintf("%s(%s)", aws.StringValue(nodegroup.Version), aws.StringValue(nodegroup.ReleaseVersion))
+}
+
+// Get min, desired and max size of nodegroup
+func getNodegroupScaling(nodegroup *eks.Nodegroup) (string, string, string) {
+	if nodegroup.ScalingConfig == nil {
+		return utils.NO_STRING, utils.NO_STRING, utils.NO_STRING
+	}
+
+	scaling := nodegroup.ScalingConfig
+	return strconv.FormatInt(aws.Int64Value(scaling.MinSize), 10),
+		strconv.FormatInt(aws.Int64Value(scaling.DesiredSize), 10),
+		strconv.FormatInt(aws.Int64Value(scaling.MaxSize), 10)
+}
+
+// Disk size is set in launch template if the nodegroup uses one
+func getNodegroupDiskSize(nodegroup *eks.Nodegroup) string {
+	if nodegroup.DiskSize == nil {
+		return utils.NO_STRING
+	}
+
+	return fmt.Sprintf("%dGiB", aws.Int64Value(nodegroup.DiskSize))
+}
+
+// Get sorted labels of nodegroup
+func getNodegroupLabels(nodegroup *eks.Nodegroup) []string {
+	labels := []string{}
+	for key, value := range nodegroup.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%s", key, aws.StringValue(value)))
+	}
+	sort.Strings(labels)
+
+	return labels
+}
+
+// Get taints of nodegroup in the format of kubectl
+func getNodegroupTaints(nodegroup *eks.Nodegroup) []string {
+	taints := []string{}
+	for _, taint := range nodegroup.Taints {
+		effect := aws.StringValue(taint.Effect)
+		if converted, ok := NODEGROUP_TAINT_EFFECTS[effect]; ok {
+			effect = converted
+		}
+
+		taints = append(taints, fmt.Sprintf("%s=%s:%s", aws.StringValue(taint.Key), aws.StringValue(taint.Value), effect))
+	}
+
+	return taints
+}
+
+// Get launch template of nodegroup with its version
+func getNodegroupLaunchTemplate(nodegroup *eks.Nodegroup) string {
+	launchTemplate := nodegroup.LaunchTemplate
+	if launchTemplate == nil {
+		return utils.NO_STRING
+	}
+
+	name := aws.StringValue(launchTemplate.Name)
+	if len(name) == 0 {
+		name = aws.StringValue(launchTemplate.Id)
+	}
+
+	return fmt.Sprintf("%s(%s)", name, aws.StringValue(launchTemplate.Version))
+}
+
+// Merge sizes given by flags into current scaling configuration.
+// Sizes which are not given keep current values
+func GetNodegroupScalingConfig(current *eks.NodegroupScalingConfig, min, desired, max int64) (*eks.NodegroupScalingConfig, error) {
+	scaling := &eks.NodegroupScalingConfig{}
+	if current != nil {
+		scaling.MinSize = current.MinSize
+		scaling.DesiredSize = current.DesiredSize
+		scaling.MaxSize = current.MaxSize
+	}
+
+	if min == NODEGROUP_SIZE_UNSET && desired == NODEGROUP_SIZE_UNSET && max == NODEGROUP_SIZE_UNSET {
+		return nil, fmt.Errorf("at least one of --min, --desired and --max is required")
+	}
+
+	if min < NODEGROUP_SIZE_UNSET || desired < NODEGROUP_SIZE_UNSET || max < NODEGROUP_SIZE_UNSET {
+		return nil, fmt.Errorf("sizes of nodegroup should not be negative")
+	}
+
+	if min != NODEGROUP_SIZE_UNSET {
+		scaling.MinSize = aws.Int64(min)
+	}
+	if desired != NODEGROUP_SIZE_UNSET {
+		scaling.DesiredSize = aws.Int64(desired)
+	}
+	if max != NODEGROUP_SIZE_UNSET {
+		scaling.MaxSize = aws.Int64(max)
+	}
+
+	minSize, desiredSize, maxSize := aws.Int64Value(scaling.MinSize), aws.Int64Value(scaling.DesiredSize), aws.Int64Value(scaling.MaxSize)
+	if maxSize < 1 {
+		return nil, fmt.Errorf("max size should be at least 1")
+	}
+	if minSize > desiredSize || desiredSize > maxSize {
+		return nil, fmt.Errorf("sizes should satisfy min(%d) <= desired(%d) <= max(%d)", minSize, desiredSize, maxSize)
+	}
+
+	return scaling, nil
+}
+
+// Get status of nodes in the nodegroup from kubernetes API
+func GetNodegroupNodeStatus(ctx context.Context, clientset *kubernetes.Clientset, nodegroup string) (map[string]string, error) {
+	listOpt := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", NODEGROUP_LABEL, nodegroup)}
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, listOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]string{}
+	for _, node := range nodes.Items {
+		ret[node.Name] = getNodeStatus(node)
+	}
+
+	return ret, nil
+}
+
+// Compare status of nodes in the nodegroup
+func DiffNodeStatus(before, after map[string]string) []NodeChange {
+	changes := []NodeChange{}
+	for node, status := range after {
+		previous, ok := before[node]
+		if !ok {
+			changes = append(changes, NodeChange{Action: NODE_CHANGE_JOINED, Node: node, Status: status})
+		} else if previous != status {
+			changes = append(changes, NodeChange{Action: NODE_CHANGE_UPDATED, Node: node, Status: status})
+		}
+	}
+
+	for node, status := range before {
+		if _, ok := after[node]; !ok {
+			changes = append(changes, NodeChange{Action: NODE_CHANGE_LEFT, Node: node, Status: status})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Node < changes[j].Node
+	})
+
+	return changes
+}
+
+// Get readiness of node like kubectl
+func getNodeStatus(node corev1.Node) string {
+	status := "NotReady"
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			if condition.Status == corev1.ConditionTrue {
+				status = "Ready"
+			}
+			break
+		}
+	}
+
+	if node.Spec.Unschedulable {
+		status += ",SchedulingDisabled"
+	}
+
+	return status
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+func TestGetNodegroupScalingConfig(t *testing.T) {
+	current := &eks.NodegroupScalingConfig{MinSize: aws.Int64(1), DesiredSize: aws.Int64(2), MaxSize: aws.Int64(3)}
+	unset := NODEGROUP_SIZE_UNSET
+
+	tcs := []struct {
+		name              string
+		current           *eks.NodegroupScalingConfig
+		min, desired, max int64
+		expectedMin       int64
+		expectedDesired   int64
+		expectedMax       int64
+		expectErr         bool
+	}{
+		{name: "desired only", current: current, min: unset, desired: 3, max: unset, expectedMin: 1, expectedDesired: 3, expectedMax: 3},
+		{name: "all sizes", current: current, min: 0, desired: 5, max: 10, expectedMin: 0, expectedDesired: 5, expectedMax: 10},
+		{name: "without current configuration", current: nil, min: 1, desired: 1, max: 1, expectedMin: 1, expectedDesired: 1, expectedMax: 1},
+		{name: "no size given", current: current, min: unset, desired: unset, max: unset, expectErr: true},
+		{name: "negative size", current: current, min: unset, desired: -2, max: unset, expectErr: true},
+		{name: "desired over max", current: current, min: unset, desired: 4, max: unset, expectErr: true},
+		{name: "min over desired", current: current, min: 3, desired: unset, max: unset, expectErr: true},
+		{name: "zero max", current: current, min: 0, desired: 0, max: 0, expectErr: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			scaling, err := GetNodegroupScalingConfig(tc.current, tc.min, tc.desired, tc.max)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", scaling)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			minSize, desiredSize, maxSize := aws.Int64Value(scaling.MinSize), aws.Int64Value(scaling.DesiredSize), aws.Int64Value(scaling.MaxSize)
+			if minSize != tc.expectedMin || desiredSize != tc.expectedDesired || maxSize != tc.expectedMax {
+				t.Errorf("expected %d/%d/%d, got %d/%d/%d", tc.expectedMin, tc.expectedDesired, tc.expectedMax, minSize, desiredSize, maxSize)
+			}
+		})
+	}
+
+	// Current configuration should not be modified
+	if aws.Int64Value(current.DesiredSize) != 2 {
+		t.Errorf("current configuration is modified: %+v", current)
+	}
+}
+
+func TestDiffNodeStatus(t *testing.T) {
+	tcs := []struct {
+		name     string
+		before   map[string]string
+		after    map[string]string
+		expected []NodeChange
+	}{
+		{
+			name:     "no change",
+			before:   map[string]string{"node-a": "Ready"},
+			after:    map[string]string{"node-a": "Ready"},
+			expected: []NodeChange{},
+		},
+		{
+			name:   "joined, updated and left",
+			before: map[string]string{"node-a": "Ready", "node-b": "Ready"},
+			after:  map[string]string{"node-b": "Ready,SchedulingDisabled", "node-c": "NotReady"},
+			expected: []NodeChange{
+				{Action: NODE_CHANGE_LEFT, Node: "node-a", Status: "Ready"},
+				{Action: NODE_CHANGE_UPDATED, Node: "node-b", Status: "Ready,SchedulingDisabled"},
+				{Action: NODE_CHANGE_JOINED, Node: "node-c", Status: "NotReady"},
+			},
+		},
+		{
+			name:     "first nodes",
+			before:   map[string]string{},
+			after:    map[string]string{"node-a": "NotReady"},
+			expected: []NodeChange{{Action: NODE_CHANGE_JOINED, Node: "node-a", Status: "NotReady"}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := DiffNodeStatus(tc.before, tc.after)
+			if !reflect.DeepEqual(changes, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, changes)
+			}
+		})
+	}
+}
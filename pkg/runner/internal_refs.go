@@ -0,0 +1,316 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	REF_KIND_CONFIGMAP = "configmap"
+	REF_KIND_SECRET    = "secret"
+
+	// Groups of kinds whose served version differs by kubernetes version, in the order of preference
+	CRONJOB_GROUP_KINDS = []schema.GroupKind{{Group: "batch", Kind: "CronJob"}}
+	INGRESS_GROUP_KINDS = []schema.GroupKind{{Group: "networking.k8s.io", Kind: "Ingress"}, {Group: "extensions", Kind: "Ingress"}}
+)
+
+// Pod spec in the template of workload
+type WorkloadPodSpec struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Spec      corev1.PodSpec
+}
+
+// Workload referencing configmap or secret
+type ConfigReference struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Target    string
+	Locations []string
+}
+
+// Get pod specs of deployments, statefulsets, daemonsets and cronjobs
+func GetAllWorkloadPodSpecs(ctx context.Context, clientset kubernetes.Interface, client dynamic.Interface, mapper meta.RESTMapper, namespace string) ([]WorkloadPodSpec, error) {
+	ret := []WorkloadPodSpec{}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range deployments.Items {
+		ret = append(ret, WorkloadPodSpec{Kind: "Deployment", Namespace: obj.Namespace, Name: obj.Name, Spec: obj.Spec.Template.Spec})
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range statefulSets.Items {
+		ret = append(ret, WorkloadPodSpec{Kind: "StatefulSet", Namespace: obj.Namespace, Name: obj.Name, Spec: obj.Spec.Template.Spec})
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range daemonSets.Items {
+		ret = append(ret, WorkloadPodSpec{Kind: "DaemonSet", Namespace: obj.Namespace, Name: obj.Name, Spec: obj.Spec.Template.Spec})
+	}
+
+	// CronJob is listed with the version served by the cluster
+	cronJobs, served, err := listServedKind(ctx, client, mapper, namespace, CRONJOB_GROUP_KINDS)
+	if err != nil {
+		return nil, err
+	}
+	if served {
+		for _, obj := range cronJobs.Items {
+			podSpec, found, err := unstructured.NestedMap(obj.Object, "spec", "jobTemplate", "spec", "template", "spec")
+			if err != nil || !found {
+				continue
+			}
+
+			var spec corev1.PodSpec
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpec, &spec); err != nil {
+				return nil, err
+			}
+			ret = append(ret, WorkloadPodSpec{Kind: "CronJob", Namespace: obj.GetNamespace(), Name: obj.GetName(), Spec: spec})
+		}
+	}
+
+	return ret, nil
+}
+
+// Find all references to configmaps or secrets in workloads, service accounts and ingresses
+func FindAllConfigReferences(ctx context.Context, clientset kubernetes.Interface, client dynamic.Interface, mapper meta.RESTMapper, namespace, kind string) ([]ConfigReference, error) {
+	workloads, err := GetAllWorkloadPodSpecs(ctx, clientset, client, mapper, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []ConfigReference{}
+	for _, workload := range workloads {
+		for target, locations := range findPodSpecReferences(workload.Spec, kind) {
+			ret = append(ret, ConfigReference{Kind: workload.Kind, Namespace: workload.Namespace, Name: workload.Name, Target: target, Locations: locations})
+		}
+	}
+
+	if kind != REF_KIND_SECRET {
+		return ret, nil
+	}
+
+	// Secrets could also be referenced by service accounts and ingresses
+	serviceAccounts, err := clientset.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sa := range serviceAccounts.Items {
+		refs := map[string][]string{}
+		for _, secret := range sa.Secrets {
+			refs[secret.Name] = append(refs[secret.Name], "secrets")
+		}
+		for _, secret := range sa.ImagePullSecrets {
+			refs[secret.Name] = append(refs[secret.Name], "imagePullSecrets")
+		}
+
+		for target, locations := range refs {
+			ret = append(ret, ConfigReference{Kind: "ServiceAccount", Namespace: sa.Namespace, Name: sa.Name, Target: target, Locations: locations})
+		}
+	}
+
+	ingresses, served, err := listServedKind(ctx, client, mapper, namespace, INGRESS_GROUP_KINDS)
+	if err != nil {
+		return nil, err
+	}
+	if served {
+		for _, ingress := range ingresses.Items {
+			tlsList, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "tls")
+			for _, item := range tlsList {
+				tls, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				secretName, _, _ := unstructured.NestedString(tls, "secretName")
+				if len(secretName) == 0 {
+					continue
+				}
+
+				hosts, _, _ := unstructured.NestedStringSlice(tls, "hosts")
+				ret = append(ret, ConfigReference{Kind: "Ingress", Namespace: ingress.GetNamespace(), Name: ingress.GetName(), Target: secretName, Locations: []string{"tls:" + strings.Join(hosts, ",")}})
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// List objects with the first group of kind served in the cluster.
+// served is false if none of groups is served
+func listServedKind(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, namespace string, groupKinds []schema.GroupKind) (*unstructured.UnstructuredList, bool, error) {
+	for _, groupKind := range groupKinds {
+		mapping, err := mapper.RESTMapping(groupKind)
+		if meta.IsNoMatchError(err) {
+			// The group is not served in the cluster
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		list, err := client.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, true, err
+		}
+
+		return list, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// Find references in pod spec, grouped by configmap or secret name
+func findPodSpecReferences(spec corev1.PodSpec, kind string) map[string][]string {
+	refs := map[string][]string{}
+	add := func(name, location string) {
+		if len(name) > 0 {
+			refs[name] = append(refs[name], location)
+		}
+	}
+
+	containers := append([]corev1.Container{}, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+
+			if kind == REF_KIND_CONFIGMAP && env.ValueFrom.ConfigMapKeyRef != nil {
+				add(env.ValueFrom.ConfigMapKeyRef.Name, fmt.Sprintf("env:%s/%s", container.Name, env.Name))
+			}
+
+			if kind == REF_KIND_SECRET && env.ValueFrom.SecretKeyRef != nil {
+				add(env.ValueFrom.SecretKeyRef.Name, fmt.Sprintf("env:%s/%s", container.Name, env.Name))
+			}
+		}
+
+		for _, envFrom := range container.EnvFrom {
+			if kind == REF_KIND_CONFIGMAP && envFrom.ConfigMapRef != nil {
+				add(envFrom.ConfigMapRef.Name, fmt.Sprintf("envFrom:%s", container.Name))
+			}
+
+			if kind == REF_KIND_SECRET && envFrom.SecretRef != nil {
+				add(envFrom.SecretRef.Name, fmt.Sprintf("envFrom:%s", container.Name))
+			}
+		}
+	}
+
+	for _, volume := range spec.Volumes {
+		if kind == REF_KIND_CONFIGMAP && volume.ConfigMap != nil {
+			add(volume.ConfigMap.Name, fmt.Sprintf("volume:%s", volume.Name))
+		}
+
+		if kind == REF_KIND_SECRET && volume.Secret != nil {
+			add(volume.Secret.SecretName, fmt.Sprintf("volume:%s", volume.Name))
+		}
+
+		if volume.Projected == nil {
+			continue
+		}
+
+		for _, source := range volume.Projected.Sources {
+			if kind == REF_KIND_CONFIGMAP && source.ConfigMap != nil {
+				add(source.ConfigMap.Name, fmt.Sprintf("projected:%s", volume.Name))
+			}
+
+			if kind == REF_KIND_SECRET && source.Secret != nil {
+				add(source.Secret.Name, fmt.Sprintf("projected:%s", volume.Name))
+			}
+		}
+	}
+
+	if kind == REF_KIND_SECRET {
+		for _, secret := range spec.ImagePullSecrets {
+			add(secret.Name, "imagePullSecrets")
+		}
+	}
+
+	return refs
+}
+
+// Render references to a configmap or secret
+func RenderConfigReferenceList(references []ConfigReference) bool {
+	if len(references) <= 0 {
+		return false
+	}
+
+	//Check Namespace
+	namespace, err := GetNamespace()
+	if err != nil {
+		return false
+	}
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader(combineNamespace([]string{"KIND", "NAME", "TARGET", "REFERENCED IN"}, true, namespace, utils.NO_STRING))
+
+	for _, reference := range references {
+		table.Append(combineNamespace([]string{reference.Kind, reference.Name, reference.Target, strings.Join(reference.Locations, ",")}, false, namespace, reference.Namespace))
+	}
+	table.Render()
+
+	return true
+}
+
+// Render configmaps or secrets with the number of references
+func RenderConfigUsageList(objects []metav1.ObjectMeta, references []ConfigReference) bool {
+	if len(objects) <= 0 {
+		return false
+	}
+
+	//Check Namespace
+	namespace, err := GetNamespace()
+	if err != nil {
+		return false
+	}
+
+	referencedBy := map[string][]string{}
+	for _, reference := range references {
+		key := reference.Namespace + "/" + reference.Target
+		referencedBy[key] = append(referencedBy[key], reference.Kind+"/"+reference.Name)
+	}
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader(combineNamespace([]string{"NAME", "STATUS", "REFERENCE COUNT", "REFERENCED BY"}, true, namespace, utils.NO_STRING))
+
+	for _, objectMeta := range objects {
+		users := referencedBy[objectMeta.Namespace+"/"+objectMeta.Name]
+
+		status := "IN USE"
+		if len(users) == 0 {
+			status = "UNUSED"
+		}
+
+		table.Append(combineNamespace([]string{objectMeta.Name, status, strconv.Itoa(len(users)), strings.Join(users, ",")}, false, namespace, objectMeta.Namespace))
+	}
+	table.Render()
+
+	return true
+}
@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/GwonsooLee/kubenx/pkg/table"
+)
+
+var (
+	// Paths of pod spec in workload resources
+	POD_SPEC_PATHS = [][]string{
+		{"spec"},
+		{"spec", "template", "spec"},
+		{"spec", "jobTemplate", "spec", "template", "spec"},
+	}
+)
+
+// Filter for searching resources
+type SearchFilter struct {
+	LabelSelector string
+	Annotations   []string
+	Image         string
+	Name          *regexp.Regexp
+}
+
+// Resource matched with search filter
+type SearchResult struct {
+	Kind              string
+	Namespace         string
+	Name              string
+	Matched           []string
+	CreationTimestamp time.Time
+}
+
+// Get REST mapper which resolves resource names, short names and CRDs
+func GetRESTMapper(config *rest.Config) (meta.RESTMapper, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := memory.NewMemCacheClient(discoveryClient)
+	return restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached), nil
+}
+
+// Resolve resource type like "deploy" or "certificates.cert-manager.io" to REST mapping
+func GetRESTMappingForResource(mapper meta.RESTMapper, resource string) (*meta.RESTMapping, error) {
+	groupResource := schema.ParseGroupResource(resource)
+	gvr, err := mapper.ResourceFor(groupResource.WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// Search resources of the mapping with filter
+func SearchResources(ctx context.Context, client dynamic.Interface, mapping *meta.RESTMapping, namespace string, filter SearchFilter) ([]SearchResult, error) {
+	var resourceClient dynamic.ResourceInterface = client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resourceClient = client.Resource(mapping.Resource).Namespace(namespace)
+	}
+
+	list, err := resourceClient.List(ctx, metav1.ListOptions{LabelSelector: filter.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []SearchResult{}
+	for _, item := range list.Items {
+		matched, ok := matchSearchFilter(item, filter)
+		if !ok {
+			continue
+		}
+
+		ret = append(ret, SearchResult{
+			Kind:              mapping.GroupVersionKind.Kind,
+			Namespace:         item.GetNamespace(),
+			Name:              item.GetName(),
+			Matched:           matched,
+			CreationTimestamp: item.GetCreationTimestamp().Time,
+		})
+	}
+
+	return ret, nil
+}
+
+// Check whether the object matches all conditions of filter
+func matchSearchFilter(item unstructured.Unstructured, filter SearchFilter) ([]string, bool) {
+	matched := []string{}
+
+	if len(filter.LabelSelector) > 0 {
+		matched = append(matched, filter.LabelSelector)
+	}
+
+	if filter.Name != nil {
+		if !filter.Name.MatchString(item.GetName()) {
+			return nil, false
+		}
+		matched = append(matched, fmt.Sprintf("name=~%s", filter.Name.String()))
+	}
+
+	annotations := item.GetAnnotations()
+	for _, annotation := range filter.Annotations {
+		parts := strings.SplitN(annotation, "=", 2)
+		value, ok := annotations[parts[0]]
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			return nil, false
+		}
+		matched = append(matched, fmt.Sprintf("%s=%s", parts[0], value))
+	}
+
+	if len(filter.Image) > 0 {
+		found := false
+		for _, image := range GetPodSpecImages(item) {
+			if strings.Contains(image, filter.Image) {
+				matched = append(matched, fmt.Sprintf("image=%s", image))
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	return matched, true
+}
+
+// Get container images in pod spec of workload
+func GetPodSpecImages(item unstructured.Unstructured) []string {
+	images := []string{}
+	for _, path := range POD_SPEC_PATHS {
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, found, err := unstructured.NestedSlice(item.Object, append(path, field)...)
+			if !found || err != nil {
+				continue
+			}
+
+			for _, container := range containers {
+				c, ok := container.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				if image, ok := c["image"].(string); ok {
+					images = append(images, image)
+				}
+			}
+		}
+	}
+
+	return images
+}
+
+// Render search result list
+func RenderSearchResultList(results []SearchResult) bool {
+	if len(results) <= 0 {
+		return false
+	}
+
+	// Table setup
+	table := table.GetTableObject()
+	table.SetHeader([]string{"KIND", "NAMESPACE", "NAME", "MATCHED", "AGE"})
+
+	now := time.Now()
+	for _, result := range results {
+		duration := duration.HumanDuration(now.Sub(result.CreationTimestamp))
+		table.Append([]string{result.Kind, result.Namespace, result.Name, strings.Join(result.Matched, ","), duration})
+	}
+	table.Render()
+
+	return true
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	SECRET_MASK = "********"
+)
+
+// Docker config json in image pull secret
+type DockerConfigJSON struct {
+	Auths map[string]DockerConfigEntry `json:"auths"`
+}
+
+// Credential for a single registry
+type DockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+	Auth     string `json:"auth"`
+}
+
+// Get sorted key list of secret
+func GetSecretKeys(secret corev1.Secret) []string {
+	keys := []string{}
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Render decoded values of secret
+func RenderSecretData(out io.Writer, secret corev1.Secret, keys []string, reveal bool) {
+	for _, key := range keys {
+		value := secret.Data[key]
+
+		color.Yellow.Fprintln(out, fmt.Sprintf("========%s=======", key))
+		switch {
+		case key == corev1.DockerConfigJsonKey || key == corev1.DockerConfigKey:
+			if err := renderDockerConfig(out, key, value, reveal); err != nil {
+				color.Red.Fprintln(out, err.Error())
+				fmt.Fprintln(out, maskSecretValue(value, reveal))
+			}
+		case IsPEMCertificate(value):
+			if err := renderCertificates(out, value); err != nil {
+				color.Red.Fprintln(out, err.Error())
+				fmt.Fprintln(out, maskSecretValue(value, reveal))
+			}
+		default:
+			fmt.Fprintln(out, maskSecretValue(value, reveal))
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// Render certificate chain in secret
+func renderCertificates(out io.Writer, value []byte) error {
+	certs, err := ParsePEMCertificates(value)
+	if err != nil {
+		return err
+	}
+
+	table := table.GetTableObjectWithWriter(out)
+	table.SetHeader([]string{"SUBJECT", "ISSUER", "SANS", "NOT BEFORE", "NOT AFTER", "DAYS LEFT"})
+
+	now := time.Now()
+	for _, cert := range certs {
+		table.Append([]string{cert.Subject.String(), cert.Issuer.String(), strings.Join(GetSubjectAlternativeNames(cert), ","), cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339), fmt.Sprintf("%d", GetDaysUntilExpiry(cert, now))})
+	}
+	table.Render()
+
+	return nil
+}
+
+// Render registry credentials in docker config
+func renderDockerConfig(out io.Writer, key string, value []byte, reveal bool) error {
+	dockerConfig := DockerConfigJSON{}
+	if key == corev1.DockerConfigJsonKey {
+		if err := json.Unmarshal(value, &dockerConfig); err != nil {
+			return err
+		}
+	} else {
+		// .dockercfg has no auths wrapper
+		if err := json.Unmarshal(value, &dockerConfig.Auths); err != nil {
+			return err
+		}
+	}
+
+	registries := []string{}
+	for registry := range dockerConfig.Auths {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	table := table.GetTableObjectWithWriter(out)
+	table.SetHeader([]string{"REGISTRY", "USERNAME", "PASSWORD", "EMAIL"})
+
+	for _, registry := range registries {
+		entry := dockerConfig.Auths[registry]
+
+		// auth field is base64 encoded username:password
+		if len(entry.Username) == 0 && len(entry.Auth) > 0 {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err == nil {
+				parts := strings.SplitN(string(decoded), ":", 2)
+				entry.Username = parts[0]
+				if len(parts) == 2 {
+					entry.Password = parts[1]
+				}
+			}
+		}
+
+		table.Append([]string{registry, entry.Username, maskSecretValue([]byte(entry.Password), reveal), entry.Email})
+	}
+	table.Render()
+
+	return nil
+}
+
+// Mask secret value if reveal is not set
+func maskSecretValue(value []byte, reveal bool) string {
+	if !reveal {
+		return fmt.Sprintf("%s (%d bytes)", SECRET_MASK, len(value))
+	}
+
+	if !utf8.Valid(value) {
+		return fmt.Sprintf("(binary, base64 encoded) %s", base64.StdEncoding.EncodeToString(value))
+	}
+
+	return string(value)
+}
@@ -0,0 +1,213 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	SESSION_DIR = filepath.Join(utils.HomeDir(), utils.KUBENX_HOMEDIR, "sessions")
+)
+
+// Access to kubeconfig files of the list, used instead of KUBECONFIG environment variable
+type fileListConfigAccess struct {
+	*clientcmd.PathOptions
+	files []string
+}
+
+// Files are loaded in the order of the list
+func (a *fileListConfigAccess) GetLoadingPrecedence() []string {
+	return a.files
+}
+
+// Get merged configuration of files in the list
+func (a *fileListConfigAccess) GetStartingConfig() (*api.Config, error) {
+	loadingRules := *a.LoadingRules
+	loadingRules.Precedence = a.files
+
+	rawConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&loadingRules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if os.IsNotExist(err) {
+		return api.NewConfig(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawConfig, nil
+}
+
+// New entries are written to the first existing file, or the last one if none exists, like KUBECONFIG
+func (a *fileListConfigAccess) GetDefaultFilename() string {
+	for _, file := range a.files {
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+
+	return a.files[len(a.files)-1]
+}
+
+// Get session kubeconfig of current shell. It is the first file of KUBECONFIG in session directory
+func GetSessionKubeconfig() (string, bool) {
+	// Explicit kubeconfig file is used instead of KUBECONFIG
+	if len(KubeconfigPath) > 0 {
+		return utils.NO_STRING, false
+	}
+
+	paths := filepath.SplitList(os.Getenv(clientcmd.RecommendedConfigPathEnvVar))
+	if len(paths) == 0 {
+		return utils.NO_STRING, false
+	}
+
+	if filepath.Dir(paths[0]) != SESSION_DIR {
+		return utils.NO_STRING, false
+	}
+
+	return paths[0], true
+}
+
+// Check whether session kubeconfig of the shell exists.
+// client-go skips missing files in KUBECONFIG, so the shell would silently use current context of other files
+func CheckSessionKubeconfig() error {
+	path, ok := GetSessionKubeconfig()
+	if !ok {
+		return nil
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("session kubeconfig %s doesn't exist, start a new session with --session or unset KUBECONFIG", path)
+	}
+
+	return nil
+}
+
+// Get access to kubeconfig files of user without session kubeconfig of the shell.
+// Clusters, users and contexts managed by kubenx are written to them, because session kubeconfig is removed later
+func GetUserConfigAccess() clientcmd.ConfigAccess {
+	configAccess := GetConfigAccess()
+
+	if _, ok := GetSessionKubeconfig(); !ok {
+		return configAccess
+	}
+
+	// Default kubeconfig is used if KUBECONFIG has only session kubeconfig
+	files := getUserKubeconfigFiles(os.Getenv(clientcmd.RecommendedConfigPathEnvVar))
+	if len(files) == 0 {
+		configAccess.EnvVar = utils.NO_STRING
+		return configAccess
+	}
+
+	return &fileListConfigAccess{PathOptions: configAccess, files: files}
+}
+
+// Get kubeconfig files in the list except session kubeconfig files
+func getUserKubeconfigFiles(kubeconfig string) []string {
+	ret := []string{}
+	for _, path := range filepath.SplitList(kubeconfig) {
+		if len(path) == 0 || filepath.Dir(path) == SESSION_DIR || utils.IsStringInArray(path, ret) {
+			continue
+		}
+		ret = append(ret, path)
+	}
+
+	return ret
+}
+
+// Create new session kubeconfig and return KUBECONFIG value which should be exported in the shell
+func CreateSessionKubeconfig() (string, string, error) {
+	if err := os.MkdirAll(SESSION_DIR, 0700); err != nil {
+		return utils.NO_STRING, utils.NO_STRING, err
+	}
+
+	pruneSessionKubeconfigs()
+
+	// Session belongs to the shell evaluating the output, which is the parent process
+	path := filepath.Join(SESSION_DIR, fmt.Sprintf("%d-%d.yaml", time.Now().Unix(), os.Getppid()))
+	if err := clientcmd.WriteToFile(*api.NewConfig(), path); err != nil {
+		return utils.NO_STRING, utils.NO_STRING, err
+	}
+
+	// Keep original kubeconfig files after session file
+	originals := []string{KubeconfigPath}
+	if len(KubeconfigPath) == 0 {
+		originals = getUserKubeconfigFiles(os.Getenv(clientcmd.RecommendedConfigPathEnvVar))
+	}
+	if len(originals) == 0 {
+		originals = []string{clientcmd.RecommendedHomeFile}
+	}
+
+	return path, strings.Join(append([]string{path}, originals...), string(filepath.ListSeparator)), nil
+}
+
+// Write context and namespace to session kubeconfig. Original kubeconfig files are untouched
+func WriteSessionContext(path string, merged api.Config, contextName, namespace string) error {
+	target, ok := merged.Contexts[contextName]
+	if !ok {
+		return fmt.Errorf("context %s doesn't exist in kubeconfig", contextName)
+	}
+
+	session, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Context in the first file of KUBECONFIG takes precedence over the same one in others
+	sessionContext := target.DeepCopy()
+	sessionContext.LocationOfOrigin = utils.NO_STRING
+	if len(namespace) > 0 {
+		sessionContext.Namespace = namespace
+	}
+
+	session.CurrentContext = contextName
+	session.Contexts[contextName] = sessionContext
+
+	return clientcmd.WriteToFile(*session, path)
+}
+
+// Remove session kubeconfig files of shells which have exited.
+// Files are kept while the shell is alive, even if the context is not switched for a long time
+func pruneSessionKubeconfigs() {
+	files, err := ioutil.ReadDir(SESSION_DIR)
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		pid, ok := getSessionPid(file.Name())
+		if ok && !isProcessAlive(pid) {
+			os.Remove(filepath.Join(SESSION_DIR, file.Name()))
+		}
+	}
+}
+
+// Get process ID of the shell from session kubeconfig name, <unix time>-<pid>.yaml
+func getSessionPid(name string) (int, bool) {
+	parts := strings.Split(strings.TrimSuffix(name, filepath.Ext(name)), "-")
+	if len(parts) != 2 {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(parts[1])
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, true
+}
+
+// Check whether the process exists. Signal 0 only checks permission to send signal
+func isProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,581 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+)
+
+var (
+	SYNC_ACTION_ADD    = "add"
+	SYNC_ACTION_UPDATE = "update"
+	SYNC_ACTION_PRUNE  = "prune"
+
+	// Order of actions in the diff
+	SYNC_ACTION_ORDER = map[string]int{SYNC_ACTION_ADD: 0, SYNC_ACTION_UPDATE: 1, SYNC_ACTION_PRUNE: 2}
+
+	CLUSTER_STATUS_CREATED = "created"
+	CLUSTER_STATUS_UPDATED = "updated"
+	CLUSTER_STATUS_FAILED  = "failed"
+)
+
+// EKS cluster written to kubeconfig
+type EKSClusterConfig struct {
+	Name                 string
+	ContextName          string
+	AccountAlias         string
+	Arn                  string
+	Account              string
+	Region               string
+	Endpoint             string
+	CertificateAuthority []byte
+	Role                 string
+}
+
+// Result of writing EKS cluster to kubeconfig
+type ClusterResult struct {
+	Account string
+	Region  string
+	Cluster string
+	Context string
+	Role    string
+	Status  string
+	Message string
+}
+
+// Change of kubeconfig to be in sync with EKS clusters
+type ConfigChange struct {
+	Action  string
+	Context string
+	Detail  string
+	Cluster EKSClusterConfig
+
+	// Context is renamed with context name template
+	Rename bool
+}
+
+// Get cluster configuration from EKS cluster description
+func NewEKSClusterConfig(cluster *eks.Cluster, region, role string) EKSClusterConfig {
+	ca, _ := base64.StdEncoding.DecodeString(aws.StringValue(cluster.CertificateAuthority.Data))
+	parsed, _ := arn.Parse(aws.StringValue(cluster.Arn))
+
+	return EKSClusterConfig{
+		Name:                 aws.StringValue(cluster.Name),
+		ContextName:          aws.StringValue(cluster.Name),
+		Arn:                  aws.StringValue(cluster.Arn),
+		Account:              parsed.AccountID,
+		Region:               region,
+		Endpoint:             aws.StringValue(cluster.Endpoint),
+		CertificateAuthority: ca,
+		Role:                 role,
+	}
+}
+
+// Values which could be used in context name template
+type ContextNameValues struct {
+	Cluster      string
+	Region       string
+	Account      string
+	AccountAlias string
+}
+
+// Check whether the template needs alias of accounts
+func IsAccountAliasUsed(nameTemplate string) bool {
+	return strings.Contains(nameTemplate, ".AccountAlias")
+}
+
+// Get context name of the cluster from template. Cluster name is used if template is empty
+func RenderContextName(nameTemplate string, cluster EKSClusterConfig) (string, error) {
+	if len(nameTemplate) == 0 {
+		return cluster.Name, nil
+	}
+
+	tmpl, err := template.New("context").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return utils.NO_STRING, fmt.Errorf("invalid context name template: %s", err.Error())
+	}
+
+	// Account ID is used for accounts without alias
+	alias := cluster.AccountAlias
+	if len(alias) == 0 {
+		alias = cluster.Account
+	}
+
+	var name bytes.Buffer
+	values := ContextNameValues{Cluster: cluster.Name, Region: cluster.Region, Account: cluster.Account, AccountAlias: alias}
+	if err := tmpl.Execute(&name, values); err != nil {
+		return utils.NO_STRING, fmt.Errorf("invalid context name template: %s", err.Error())
+	}
+
+	if len(strings.TrimSpace(name.String())) == 0 {
+		return utils.NO_STRING, fmt.Errorf("context name of %s is empty with template %s", cluster.Arn, nameTemplate)
+	}
+
+	return name.String(), nil
+}
+
+// Get scope of region and account which clusters are searched in
+func GetClusterScope(region, account string) string {
+	return region + "/" + account
+}
+
+// Get scope of the cluster ARN. Empty string is returned if it is not EKS cluster ARN
+func getClusterArnScope(clusterArn string) string {
+	parsed, err := arn.Parse(clusterArn)
+	if err != nil || parsed.Service != "eks" || !strings.HasPrefix(parsed.Resource, "cluster/") {
+		return ""
+	}
+
+	return GetClusterScope(parsed.Region, parsed.AccountID)
+}
+
+// Get name of the context which kubenx wrote before for the cluster.
+// Context with the current name is preferred, then the one named by cluster ARN or cluster name
+func GetPreviousContextName(config *api.Config, cluster EKSClusterConfig) (string, bool) {
+	for _, name := range []string{cluster.ContextName, cluster.Arn, cluster.Name} {
+		context, ok := config.Contexts[name]
+		if ok && context.Cluster == cluster.Arn && context.AuthInfo == cluster.Arn {
+			return name, true
+		}
+	}
+
+	return utils.NO_STRING, false
+}
+
+// Compare kubeconfig with EKS clusters.
+// Only contexts written by kubenx, which use cluster ARN as cluster and user, are pruned,
+// and only if their region and account are in scanned scopes
+func DiffKubeconfig(config *api.Config, clusters []EKSClusterConfig, scanned map[string]bool) []ConfigChange {
+	changes := []ConfigChange{}
+
+	// Several contexts could use the same cluster
+	contextsByArn := map[string][]string{}
+	for name, context := range config.Contexts {
+		if len(getClusterArnScope(context.Cluster)) > 0 && context.Cluster == context.AuthInfo {
+			contextsByArn[context.Cluster] = append(contextsByArn[context.Cluster], name)
+		}
+	}
+
+	live := map[string]bool{}
+	for _, cluster := range clusters {
+		live[cluster.Arn] = true
+
+		names, ok := contextsByArn[cluster.Arn]
+		if !ok {
+			detail := cluster.Endpoint
+			if _, exists := config.Contexts[cluster.ContextName]; exists {
+				detail = "replace existing context"
+			}
+			changes = append(changes, ConfigChange{Action: SYNC_ACTION_ADD, Context: cluster.ContextName, Detail: detail, Cluster: cluster})
+			continue
+		}
+
+		sort.Strings(names)
+		name := strings.Join(names, ",")
+
+		current, ok := config.Clusters[cluster.Arn]
+		if !ok {
+			changes = append(changes, ConfigChange{Action: SYNC_ACTION_UPDATE, Context: name, Detail: "cluster entry is missing", Cluster: cluster})
+			continue
+		}
+
+		details := []string{}
+		rename := !utils.IsStringInArray(cluster.ContextName, names)
+		if rename {
+			if previous, ok := GetPreviousContextName(config, cluster); ok {
+				details = append(details, fmt.Sprintf("rename %s to %s", previous, cluster.ContextName))
+			} else {
+				details = append(details, fmt.Sprintf("add context %s", cluster.ContextName))
+			}
+		}
+		if current.Server != cluster.Endpoint {
+			details = append(details, fmt.Sprintf("endpoint %s -> %s", current.Server, cluster.Endpoint))
+		}
+		if !bytes.Equal(current.CertificateAuthorityData, cluster.CertificateAuthority) {
+			details = append(details, "certificate authority changed")
+		}
+		if len(details) > 0 {
+			changes = append(changes, ConfigChange{Action: SYNC_ACTION_UPDATE, Context: name, Detail: strings.Join(details, ", "), Cluster: cluster, Rename: rename})
+		}
+	}
+
+	for clusterArn, names := range contextsByArn {
+		if live[clusterArn] || !scanned[getClusterArnScope(clusterArn)] {
+			continue
+		}
+		for _, name := range names {
+			changes = append(changes, ConfigChange{Action: SYNC_ACTION_PRUNE, Context: name, Detail: "cluster does not exist", Cluster: EKSClusterConfig{Arn: clusterArn}})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Action != changes[j].Action {
+			return SYNC_ACTION_ORDER[changes[i].Action] < SYNC_ACTION_ORDER[changes[j].Action]
+		}
+		return changes[i].Context < changes[j].Context
+	})
+
+	return changes
+}
+
+// Apply changes to kubeconfig. New contexts are written with setCluster
+func ApplyConfigChanges(config *api.Config, changes []ConfigChange, setCluster func(*api.Config, EKSClusterConfig)) {
+	for _, change := range changes {
+		switch change.Action {
+		case SYNC_ACTION_ADD:
+			setCluster(config, change.Cluster)
+		case SYNC_ACTION_UPDATE:
+			if change.Rename {
+				setCluster(config, change.Cluster)
+				continue
+			}
+
+			// Keep user and namespace of the context as they are
+			cluster, ok := config.Clusters[change.Cluster.Arn]
+			if !ok {
+				cluster = api.NewCluster()
+				config.Clusters[change.Cluster.Arn] = cluster
+			}
+			cluster.Server = change.Cluster.Endpoint
+			cluster.CertificateAuthorityData = change.Cluster.CertificateAuthority
+		case SYNC_ACTION_PRUNE:
+			delete(config.Contexts, change.Context)
+			delete(config.Clusters, change.Cluster.Arn)
+			delete(config.AuthInfos, change.Cluster.Arn)
+			if config.CurrentContext == change.Context {
+				config.CurrentContext = ""
+			}
+		}
+	}
+}
+
+// Render changes of kubeconfig
+func RenderConfigChanges(changes []ConfigChange) bool {
+	if len(changes) <= 0 {
+		return false
+	}
+
+	table := table.GetTableObject()
+	table.SetHeader([]string{"ACTION", "CONTEXT", "CLUSTER", "DETAIL"})
+	for _, change := range changes {
+		table.Append([]string{change.Action, change.Context, change.Cluster.Arn, change.Detail})
+	}
+	table.Render()
+
+	return true
+}
+
+// Get result of failure in discovering clusters
+func GetClusterResultOfError(err error) ClusterResult {
+	// Only the first line is shown, because AWS errors could have several lines
+	message := strings.SplitN(err.Error(), "\n", 2)[0]
+
+	discoveryErr, ok := err.(*EKSDiscoveryError)
+	if !ok {
+		return ClusterResult{Status: CLUSTER_STATUS_FAILED, Message: message}
+	}
+
+	return ClusterResult{
+		Account: discoveryErr.Account,
+		Region:  discoveryErr.Region,
+		Cluster: discoveryErr.Cluster,
+		Role:    discoveryErr.Role,
+		Status:  CLUSTER_STATUS_FAILED,
+		Message: fmt.Sprintf("cannot %s: %s", discoveryErr.Action, strings.SplitN(discoveryErr.Err.Error(), "\n", 2)[0]),
+	}
+}
+
+// Get clusters failed to be described, and accounts or regions failed to be searched, from results
+func GetFailedTargets(results []ClusterResult) ([]string, []string) {
+	clusters := []string{}
+	scopes := []string{}
+	for _, result := range results {
+		if result.Status != CLUSTER_STATUS_FAILED {
+			continue
+		}
+
+		if len(result.Cluster) > 0 {
+			clusters = append(clusters, fmt.Sprintf("%s in %s", result.Cluster, result.Region))
+			continue
+		}
+
+		scope := getRoleLabel(result.Role)
+		if len(result.Account) > 0 {
+			scope = result.Account
+		}
+		if len(result.Region) > 0 {
+			scope = GetClusterScope(result.Region, scope)
+		}
+		if !utils.IsStringInArray(scope, scopes) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return clusters, scopes
+}
+
+// Render results of clusters per account
+func RenderClusterResults(results []ClusterResult) bool {
+	if len(results) <= 0 {
+		return false
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.Cluster < b.Cluster
+	})
+
+	table := table.GetTableObject()
+	table.SetHeader([]string{"ACCOUNT", "REGION", "CLUSTER", "CONTEXT", "ROLE", "STATUS", "MESSAGE"})
+	for _, result := range results {
+		table.Append([]string{result.Account, result.Region, result.Cluster, result.Context, getRoleLabel(result.Role), result.Status, result.Message})
+	}
+	table.Render()
+
+	return true
+}
+
+// Write kubeconfig at once. Every changed kubeconfig file is replaced atomically with temporary file,
+// so it is not broken even if kubenx is stopped while writing
+func WriteConfigAtomically(configAccess clientcmd.ConfigAccess, config api.Config) error {
+	files := configAccess.GetLoadingPrecedence()
+	if configAccess.IsExplicitFile() {
+		files = []string{configAccess.GetExplicitFile()}
+	}
+
+	if len(files) == 1 {
+		return writeConfigFileAtomically(files[0], config)
+	}
+
+	// Entries of several files are written to their own files like kubectl
+	starting, err := configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	configs, err := splitConfigByFile(files, configAccess.GetDefaultFilename(), starting, &config)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if fileConfig, ok := configs[file]; ok {
+			if err := writeConfigFileAtomically(file, *fileConfig); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Split changes of merged kubeconfig into configurations of files. Only changed files are returned.
+// Changed entries are written to the file they came from, and new entries to the default file
+func splitConfigByFile(files []string, defaultFile string, starting, config *api.Config) (map[string]*api.Config, error) {
+	loaded := map[string]*api.Config{}
+	changed := map[string]*api.Config{}
+
+	load := func(file string) (*api.Config, error) {
+		if fileConfig, ok := loaded[file]; ok {
+			return fileConfig, nil
+		}
+
+		fileConfig, err := clientcmd.LoadFromFile(file)
+		if os.IsNotExist(err) {
+			fileConfig, err = api.NewConfig(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		loaded[file] = fileConfig
+		return fileConfig, nil
+	}
+
+	// File of the entry in starting configuration, or default file for new entry
+	locate := func(origin string) (*api.Config, error) {
+		file := defaultFile
+		if utils.IsStringInArray(origin, files) {
+			file = origin
+		}
+
+		fileConfig, err := load(file)
+		if err != nil {
+			return nil, err
+		}
+
+		changed[file] = fileConfig
+		return fileConfig, nil
+	}
+
+	for name, cluster := range config.Clusters {
+		current, ok := starting.Clusters[name]
+		if ok && reflect.DeepEqual(current, cluster) {
+			continue
+		}
+
+		origin := cluster.LocationOfOrigin
+		if ok {
+			origin = current.LocationOfOrigin
+		}
+		fileConfig, err := locate(origin)
+		if err != nil {
+			return nil, err
+		}
+		fileConfig.Clusters[name] = cluster
+	}
+
+	for name, authInfo := range config.AuthInfos {
+		current, ok := starting.AuthInfos[name]
+		if ok && reflect.DeepEqual(current, authInfo) {
+			continue
+		}
+
+		origin := authInfo.LocationOfOrigin
+		if ok {
+			origin = current.LocationOfOrigin
+		}
+		fileConfig, err := locate(origin)
+		if err != nil {
+			return nil, err
+		}
+		fileConfig.AuthInfos[name] = authInfo
+	}
+
+	for name, context := range config.Contexts {
+		current, ok := starting.Contexts[name]
+		if ok && reflect.DeepEqual(current, context) {
+			continue
+		}
+
+		origin := context.LocationOfOrigin
+		if ok {
+			origin = current.LocationOfOrigin
+		}
+		fileConfig, err := locate(origin)
+		if err != nil {
+			return nil, err
+		}
+		fileConfig.Contexts[name] = context
+	}
+
+	// Removed entries are deleted from the file they came from
+	for name, cluster := range starting.Clusters {
+		if _, ok := config.Clusters[name]; !ok {
+			fileConfig, err := locate(cluster.LocationOfOrigin)
+			if err != nil {
+				return nil, err
+			}
+			delete(fileConfig.Clusters, name)
+		}
+	}
+
+	for name, authInfo := range starting.AuthInfos {
+		if _, ok := config.AuthInfos[name]; !ok {
+			fileConfig, err := locate(authInfo.LocationOfOrigin)
+			if err != nil {
+				return nil, err
+			}
+			delete(fileConfig.AuthInfos, name)
+		}
+	}
+
+	for name, context := range starting.Contexts {
+		if _, ok := config.Contexts[name]; !ok {
+			fileConfig, err := locate(context.LocationOfOrigin)
+			if err != nil {
+				return nil, err
+			}
+			delete(fileConfig.Contexts, name)
+		}
+	}
+
+	// Current context is written to the first file having it, like kubectl
+	if config.CurrentContext != starting.CurrentContext {
+		target := defaultFile
+		for _, file := range files {
+			fileConfig, err := load(file)
+			if err != nil {
+				return nil, err
+			}
+			if len(fileConfig.CurrentContext) > 0 {
+				target = file
+				break
+			}
+		}
+
+		fileConfig, err := locate(target)
+		if err != nil {
+			return nil, err
+		}
+		fileConfig.CurrentContext = config.CurrentContext
+	}
+
+	return changed, nil
+}
+
+// Replace kubeconfig file atomically with temporary file.
+// Paths in kubeconfig are written as they are, because kubeconfig is loaded without resolving them
+func writeConfigFileAtomically(path string, config api.Config) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	content, err := clientcmd.Write(config)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,304 @@
+package runner
+
+import (
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	testClusterArn  = "arn:aws:eks:ap-northeast-2:123456789012:cluster/prod"
+	testOtherArn    = "arn:aws:eks:ap-northeast-2:123456789012:cluster/old"
+	testForeignArn  = "arn:aws:eks:us-east-1:210987654321:cluster/foreign"
+	testEndpoint    = "https://prod.eks.amazonaws.com"
+	testNewEndpoint = "https://prod-new.eks.amazonaws.com"
+)
+
+// Get kubeconfig with contexts which use cluster ARN as cluster and user
+func newTestKubeconfig(contexts map[string]string, servers map[string]string) *api.Config {
+	config := api.NewConfig()
+	for name, clusterArn := range contexts {
+		context := api.NewContext()
+		context.Cluster = clusterArn
+		context.AuthInfo = clusterArn
+		config.Contexts[name] = context
+		config.AuthInfos[clusterArn] = api.NewAuthInfo()
+	}
+	for clusterArn, server := range servers {
+		cluster := api.NewCluster()
+		cluster.Server = server
+		config.Clusters[clusterArn] = cluster
+	}
+
+	return config
+}
+
+func TestDiffKubeconfig(t *testing.T) {
+	prod := EKSClusterConfig{Name: "prod", ContextName: "prod", Arn: testClusterArn, Endpoint: testEndpoint}
+	scanned := map[string]bool{GetClusterScope("ap-northeast-2", "123456789012"): true}
+
+	tcs := []struct {
+		name     string
+		config   *api.Config
+		clusters []EKSClusterConfig
+		expected []ConfigChange
+	}{
+		{
+			name:     "add new cluster",
+			config:   newTestKubeconfig(nil, nil),
+			clusters: []EKSClusterConfig{prod},
+			expected: []ConfigChange{{Action: SYNC_ACTION_ADD, Context: "prod", Detail: testEndpoint}},
+		},
+		{
+			name:     "no change",
+			config:   newTestKubeconfig(map[string]string{"prod": testClusterArn}, map[string]string{testClusterArn: testEndpoint}),
+			clusters: []EKSClusterConfig{prod},
+			expected: []ConfigChange{},
+		},
+		{
+			name:     "update endpoint",
+			config:   newTestKubeconfig(map[string]string{"prod": testClusterArn}, map[string]string{testClusterArn: testNewEndpoint}),
+			clusters: []EKSClusterConfig{prod},
+			expected: []ConfigChange{{Action: SYNC_ACTION_UPDATE, Context: "prod", Detail: "endpoint " + testNewEndpoint + " -> " + testEndpoint}},
+		},
+		{
+			name:     "update missing cluster entry",
+			config:   newTestKubeconfig(map[string]string{"prod": testClusterArn}, nil),
+			clusters: []EKSClusterConfig{prod},
+			expected: []ConfigChange{{Action: SYNC_ACTION_UPDATE, Context: "prod", Detail: "cluster entry is missing"}},
+		},
+		{
+			name:     "rename context",
+			config:   newTestKubeconfig(map[string]string{testClusterArn: testClusterArn}, map[string]string{testClusterArn: testEndpoint}),
+			clusters: []EKSClusterConfig{prod},
+			expected: []ConfigChange{{Action: SYNC_ACTION_UPDATE, Context: testClusterArn, Detail: "rename " + testClusterArn + " to prod", Rename: true}},
+		},
+		{
+			name: "prune removed cluster only in scanned scope",
+			config: newTestKubeconfig(
+				map[string]string{"prod": testClusterArn, "old": testOtherArn, "foreign": testForeignArn},
+				map[string]string{testClusterArn: testEndpoint, testOtherArn: testEndpoint, testForeignArn: testEndpoint},
+			),
+			clusters: []EKSClusterConfig{prod},
+			expected: []ConfigChange{{Action: SYNC_ACTION_PRUNE, Context: "old", Detail: "cluster does not exist"}},
+		},
+		{
+			name: "replace context not written by kubenx",
+			config: func() *api.Config {
+				config := newTestKubeconfig(nil, nil)
+				context := api.NewContext()
+				context.Cluster = "prod"
+				context.AuthInfo = "admin"
+				config.Contexts["prod"] = context
+				return config
+			}(),
+			clusters: []EKSClusterConfig{prod},
+			expected: []ConfigChange{{Action: SYNC_ACTION_ADD, Context: "prod", Detail: "replace existing context"}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := DiffKubeconfig(tc.config, tc.clusters, scanned)
+			if len(changes) != len(tc.expected) {
+				t.Fatalf("expected %d changes, got %d: %+v", len(tc.expected), len(changes), changes)
+			}
+			for i, change := range changes {
+				expected := tc.expected[i]
+				if change.Action != expected.Action || change.Context != expected.Context || change.Detail != expected.Detail || change.Rename != expected.Rename {
+					t.Errorf("expected change %+v, got %+v", expected, change)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyConfigChanges(t *testing.T) {
+	prod := EKSClusterConfig{Name: "prod", ContextName: "prod", Arn: testClusterArn, Endpoint: testNewEndpoint, CertificateAuthority: []byte("ca")}
+
+	// Write context named by ContextName, as config commands do
+	setCluster := func(config *api.Config, cluster EKSClusterConfig) {
+		for name, context := range config.Contexts {
+			if context.Cluster == cluster.Arn {
+				delete(config.Contexts, name)
+			}
+		}
+		context := api.NewContext()
+		context.Cluster = cluster.Arn
+		context.AuthInfo = cluster.Arn
+		config.Contexts[cluster.ContextName] = context
+		config.Clusters[cluster.Arn] = &api.Cluster{Server: cluster.Endpoint, CertificateAuthorityData: cluster.CertificateAuthority}
+	}
+
+	tcs := []struct {
+		name             string
+		config           *api.Config
+		changes          []ConfigChange
+		expectedContexts []string
+		expectedServer   string
+		expectedCurrent  string
+		expectedNS       string
+	}{
+		{
+			name:             "add context",
+			config:           newTestKubeconfig(nil, nil),
+			changes:          []ConfigChange{{Action: SYNC_ACTION_ADD, Context: "prod", Cluster: prod}},
+			expectedContexts: []string{"prod"},
+			expectedServer:   testNewEndpoint,
+		},
+		{
+			name: "update cluster and keep namespace",
+			config: func() *api.Config {
+				config := newTestKubeconfig(map[string]string{"prod": testClusterArn}, map[string]string{testClusterArn: testEndpoint})
+				config.Contexts["prod"].Namespace = "app"
+				return config
+			}(),
+			changes:          []ConfigChange{{Action: SYNC_ACTION_UPDATE, Context: "prod", Cluster: prod}},
+			expectedContexts: []string{"prod"},
+			expectedServer:   testNewEndpoint,
+			expectedNS:       "app",
+		},
+		{
+			name:             "rename context",
+			config:           newTestKubeconfig(map[string]string{testClusterArn: testClusterArn}, map[string]string{testClusterArn: testEndpoint}),
+			changes:          []ConfigChange{{Action: SYNC_ACTION_UPDATE, Context: testClusterArn, Cluster: prod, Rename: true}},
+			expectedContexts: []string{"prod"},
+			expectedServer:   testNewEndpoint,
+		},
+		{
+			name: "prune current context",
+			config: func() *api.Config {
+				config := newTestKubeconfig(map[string]string{"old": testOtherArn}, map[string]string{testOtherArn: testEndpoint})
+				config.CurrentContext = "old"
+				return config
+			}(),
+			changes:          []ConfigChange{{Action: SYNC_ACTION_PRUNE, Context: "old", Cluster: EKSClusterConfig{Arn: testOtherArn}}},
+			expectedContexts: []string{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ApplyConfigChanges(tc.config, tc.changes, setCluster)
+
+			if len(tc.config.Contexts) != len(tc.expectedContexts) {
+				t.Fatalf("expected contexts %v, got %v", tc.expectedContexts, tc.config.Contexts)
+			}
+			for _, name := range tc.expectedContexts {
+				context, ok := tc.config.Contexts[name]
+				if !ok {
+					t.Errorf("expected context %s, got %v", name, tc.config.Contexts)
+				} else if context.Namespace != tc.expectedNS {
+					t.Errorf("expected namespace %q of %s, got %q", tc.expectedNS, name, context.Namespace)
+				}
+			}
+			if tc.config.CurrentContext != tc.expectedCurrent {
+				t.Errorf("expected current context %q, got %q", tc.expectedCurrent, tc.config.CurrentContext)
+			}
+
+			if len(tc.expectedServer) == 0 {
+				if len(tc.config.Clusters) > 0 || len(tc.config.AuthInfos) > 0 {
+					t.Errorf("expected clusters and users to be pruned, got %v and %v", tc.config.Clusters, tc.config.AuthInfos)
+				}
+				return
+			}
+			if server := tc.config.Clusters[testClusterArn].Server; server != tc.expectedServer {
+				t.Errorf("expected server %s, got %s", tc.expectedServer, server)
+			}
+		})
+	}
+}
+
+func TestRenderContextName(t *testing.T) {
+	cluster := EKSClusterConfig{Name: "prod", Arn: testClusterArn, Account: "123456789012", Region: "ap-northeast-2"}
+	aliased := cluster
+	aliased.AccountAlias = "company-prod"
+
+	tcs := []struct {
+		name      string
+		template  string
+		cluster   EKSClusterConfig
+		expected  string
+		expectErr bool
+	}{
+		{name: "empty template uses cluster name", template: "", cluster: cluster, expected: "prod"},
+		{name: "cluster and region", template: "{{.Cluster}}@{{.Region}}", cluster: cluster, expected: "prod@ap-northeast-2"},
+		{name: "account alias", template: "{{.AccountAlias}}/{{.Cluster}}", cluster: aliased, expected: "company-prod/prod"},
+		{name: "account ID without alias", template: "{{.AccountAlias}}/{{.Cluster}}", cluster: cluster, expected: "123456789012/prod"},
+		{name: "unknown field", template: "{{.Unknown}}", cluster: cluster, expectErr: true},
+		{name: "invalid template", template: "{{.Cluster", cluster: cluster, expectErr: true},
+		{name: "empty name", template: "{{if false}}x{{end}} ", cluster: cluster, expectErr: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := RenderContextName(tc.template, tc.cluster)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if name != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, name)
+			}
+		})
+	}
+}
+
+func TestGetPreviousContextName(t *testing.T) {
+	cluster := EKSClusterConfig{Name: "prod", ContextName: "company/prod", Arn: testClusterArn}
+
+	tcs := []struct {
+		name       string
+		config     *api.Config
+		expected   string
+		expectedOk bool
+	}{
+		{
+			name:       "context with current name",
+			config:     newTestKubeconfig(map[string]string{"company/prod": testClusterArn, "prod": testClusterArn}, nil),
+			expected:   "company/prod",
+			expectedOk: true,
+		},
+		{
+			name:       "context named by cluster ARN",
+			config:     newTestKubeconfig(map[string]string{testClusterArn: testClusterArn, "prod": testClusterArn}, nil),
+			expected:   testClusterArn,
+			expectedOk: true,
+		},
+		{
+			name:       "context named by cluster name",
+			config:     newTestKubeconfig(map[string]string{"prod": testClusterArn}, nil),
+			expected:   "prod",
+			expectedOk: true,
+		},
+		{
+			name:   "context of another cluster",
+			config: newTestKubeconfig(map[string]string{"prod": testOtherArn}, nil),
+		},
+		{
+			name: "context not written by kubenx",
+			config: func() *api.Config {
+				config := newTestKubeconfig(nil, nil)
+				context := api.NewContext()
+				context.Cluster = testClusterArn
+				context.AuthInfo = "admin"
+				config.Contexts["prod"] = context
+				return config
+			}(),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			name, ok := GetPreviousContextName(tc.config, cluster)
+			if ok != tc.expectedOk || name != tc.expected {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tc.expected, tc.expectedOk, name, ok)
+			}
+		})
+	}
+}
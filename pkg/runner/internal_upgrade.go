@@ -0,0 +1,511 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kubenxaws "github.com/GwonsooLee/kubenx/pkg/aws"
+	"github.com/GwonsooLee/kubenx/pkg/table"
+	"github.com/GwonsooLee/kubenx/pkg/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	UPGRADE_CHECK_PASS = "PASS"
+	UPGRADE_CHECK_WARN = "WARN"
+	UPGRADE_CHECK_FAIL = "FAIL"
+
+	// Names of checks
+	UPGRADE_CHECK_TARGET_VERSION  = "Target version"
+	UPGRADE_CHECK_ADDONS          = "Add-on compatibility"
+	UPGRADE_CHECK_NODEGROUPS      = "Nodegroup version skew"
+	UPGRADE_CHECK_NODES           = "Node kubelet versions"
+	UPGRADE_CHECK_DEPRECATED_APIS = "Deprecated APIs"
+	UPGRADE_CHECK_PDBS            = "Pod disruption budgets"
+	UPGRADE_CHECK_SUBNETS         = "Subnet IP addresses"
+
+	// EKS needs up to 5 available IP addresses in cluster subnets to upgrade control plane
+	EKS_UPGRADE_MIN_SUBNET_IPS int64 = 5
+)
+
+// Result of a check for upgrade
+type UpgradeCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Whether installed add-on version is compatible with target kubernetes version
+type AddonCompatibility struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	TargetLatest  string `json:"targetLatest"`
+	TargetDefault string `json:"targetDefault"`
+	Compatible    bool   `json:"compatible"`
+}
+
+// Version of nodegroup compared with control plane
+type NodegroupSkew struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	ReleaseVersion string `json:"releaseVersion"`
+	Status         string `json:"status"`
+	Message        string `json:"message"`
+}
+
+// Pod disruption budget which allows no disruption, which blocks draining nodes
+type BlockingPDB struct {
+	Namespace          string `json:"namespace"`
+	Name               string `json:"name"`
+	ExpectedPods       int64  `json:"expectedPods"`
+	CurrentHealthy     int64  `json:"currentHealthy"`
+	DesiredHealthy     int64  `json:"desiredHealthy"`
+	DisruptionsAllowed int64  `json:"disruptionsAllowed"`
+}
+
+// Available IP addresses of subnet
+type SubnetCapacity struct {
+	SubnetId         string `json:"subnetId"`
+	Name             string `json:"name"`
+	AvailabilityZone string `json:"availabilityZone"`
+	AvailableIPs     int64  `json:"availableIPs"`
+}
+
+// Report of readiness of cluster upgrade
+type UpgradeReport struct {
+	Cluster         string               `json:"cluster"`
+	CurrentVersion  string               `json:"currentVersion"`
+	TargetVersion   string               `json:"targetVersion"`
+	PlatformVersion string               `json:"platformVersion"`
+	GeneratedAt     time.Time            `json:"generatedAt"`
+	Checks          []UpgradeCheck       `json:"checks"`
+	Addons          []AddonCompatibility `json:"addons"`
+	Nodegroups      []NodegroupSkew      `json:"nodegroups"`
+	DeprecatedAPIs  []DeprecatedAPIUsage `json:"deprecatedAPIs"`
+	BlockingPDBs    []BlockingPDB        `json:"blockingPDBs"`
+	Subnets         []SubnetCapacity     `json:"subnets"`
+	Errors          []string             `json:"errors"`
+}
+
+// Create report of the cluster for the target version
+func NewUpgradeReport(cluster *eks.Cluster, targetVersion string) *UpgradeReport {
+	currentVersion := aws.StringValue(cluster.Version)
+	if len(targetVersion) == 0 {
+		targetVersion = GetNextKubernetesVersion(currentVersion)
+	}
+
+	return &UpgradeReport{
+		Cluster:         aws.StringValue(cluster.Name),
+		CurrentVersion:  currentVersion,
+		TargetVersion:   targetVersion,
+		PlatformVersion: aws.StringValue(cluster.PlatformVersion),
+		GeneratedAt:     time.Now(),
+		Checks:          []UpgradeCheck{},
+		Addons:          []AddonCompatibility{},
+		Nodegroups:      []NodegroupSkew{},
+		DeprecatedAPIs:  []DeprecatedAPIUsage{},
+		BlockingPDBs:    []BlockingPDB{},
+		Subnets:         []SubnetCapacity{},
+		Errors:          []string{},
+	}
+}
+
+// Add check result to report
+func (r *UpgradeReport) AddCheck(name, status, message string) {
+	r.Checks = append(r.Checks, UpgradeCheck{Name: name, Status: status, Message: message})
+}
+
+// Add error which prevented the check. The check is failed, because its result is unknown
+func (r *UpgradeReport) AddError(name string, err error) {
+	r.addPartialError(name, err)
+	r.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("cannot be checked: %s", err.Error()))
+}
+
+// Add error which prevented a part of the check
+func (r *UpgradeReport) addPartialError(name string, err error) {
+	r.Errors = append(r.Errors, fmt.Sprintf("%s: %s", name, err.Error()))
+}
+
+// Check whether any check is failed or could not be done
+func (r *UpgradeReport) HasFailure() bool {
+	if len(r.Errors) > 0 {
+		return true
+	}
+
+	for _, check := range r.Checks {
+		if check.Status == UPGRADE_CHECK_FAIL {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get next minor version of kubernetes
+func GetNextKubernetesVersion(kubernetesVersion string) string {
+	current, err := version.ParseGeneric(kubernetesVersion)
+	if err != nil {
+		return kubernetesVersion
+	}
+
+	return fmt.Sprintf("%d.%d", current.Major(), current.Minor()+1)
+}
+
+// EKS upgrades control plane only to the next minor version
+func CheckTargetVersion(report *UpgradeReport) {
+	name := UPGRADE_CHECK_TARGET_VERSION
+
+	current, err := version.ParseGeneric(report.CurrentVersion)
+	if err != nil {
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("cannot parse current version %s", report.CurrentVersion))
+		return
+	}
+
+	target, err := version.ParseGeneric(report.TargetVersion)
+	if err != nil {
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("cannot parse target version %s", report.TargetVersion))
+		return
+	}
+
+	if target.Major() != current.Major() || target.Minor() != current.Minor()+1 {
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("%s can be upgraded only to %s", report.CurrentVersion, GetNextKubernetesVersion(report.CurrentVersion)))
+		return
+	}
+
+	report.AddCheck(name, UPGRADE_CHECK_PASS, fmt.Sprintf("%s -> %s", report.CurrentVersion, report.TargetVersion))
+}
+
+// Check whether installed add-ons are compatible with target version
+func CheckAddonCompatibility(report *UpgradeReport, svc *eks.EKS) {
+	name := UPGRADE_CHECK_ADDONS
+
+	addons, err := kubenxaws.ListAllAddons(svc, report.Cluster)
+	if err != nil {
+		report.AddError(name, err)
+		return
+	}
+
+	incompatible := []string{}
+	failed := []string{}
+	for _, addon := range addons {
+		info, err := kubenxaws.GetAddonInfo(svc, report.Cluster, addon)
+		if err != nil {
+			report.addPartialError(name, err)
+			failed = append(failed, addon)
+			continue
+		}
+
+		versions, err := kubenxaws.GetAddonVersions(svc, addon, report.TargetVersion)
+		if err != nil {
+			report.addPartialError(name, err)
+			failed = append(failed, addon)
+			continue
+		}
+
+		installed := aws.StringValue(info.AddonVersion)
+		latest, defaultVersion := getCompatibleAddonVersions(versions, report.TargetVersion)
+		compatible := isAddonVersionCompatible(versions, installed, report.TargetVersion)
+		if !compatible {
+			incompatible = append(incompatible, addon)
+		}
+
+		report.Addons = append(report.Addons, AddonCompatibility{
+			Name:          addon,
+			Version:       installed,
+			TargetLatest:  latest,
+			TargetDefault: defaultVersion,
+			Compatible:    compatible,
+		})
+	}
+
+	if len(failed) > 0 {
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("%s cannot be checked", strings.Join(failed, ",")))
+		return
+	}
+
+	if len(incompatible) > 0 {
+		report.AddCheck(name, UPGRADE_CHECK_WARN, fmt.Sprintf("update %s along with the upgrade", strings.Join(incompatible, ",")))
+		return
+	}
+
+	report.AddCheck(name, UPGRADE_CHECK_PASS, fmt.Sprintf("%d add-ons are compatible", len(report.Addons)))
+}
+
+// Check whether add-on version is in compatible versions of kubernetes version
+func isAddonVersionCompatible(versions []*eks.AddonVersionInfo, addonVersion, kubernetesVersion string) bool {
+	for _, info := range versions {
+		if aws.StringValue(info.AddonVersion) != addonVersion {
+			continue
+		}
+
+		for _, compatibility := range info.Compatibilities {
+			if aws.StringValue(compatibility.ClusterVersion) == kubernetesVersion {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// EKS requires nodegroups to have the same minor version as control plane before upgrade
+func CheckNodegroupSkew(report *UpgradeReport, nodegroups []*eks.Nodegroup) {
+	name := UPGRADE_CHECK_NODEGROUPS
+
+	behind := []string{}
+	for _, nodegroup := range nodegroups {
+		skew := NodegroupSkew{
+			Name:           aws.StringValue(nodegroup.NodegroupName),
+			Version:        aws.StringValue(nodegroup.Version),
+			ReleaseVersion: aws.StringValue(nodegroup.ReleaseVersion),
+			Status:         UPGRADE_CHECK_PASS,
+		}
+
+		if !isSameMinorVersion(skew.Version, report.CurrentVersion) {
+			skew.Status = UPGRADE_CHECK_FAIL
+			skew.Message = fmt.Sprintf("upgrade nodegroup to %s first", report.CurrentVersion)
+			behind = append(behind, skew.Name)
+		} else {
+			skew.Message = fmt.Sprintf("upgrade nodegroup to %s after control plane", report.TargetVersion)
+		}
+
+		report.Nodegroups = append(report.Nodegroups, skew)
+	}
+
+	if len(behind) > 0 {
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("%s should be %s before upgrade", strings.Join(behind, ","), report.CurrentVersion))
+		return
+	}
+
+	report.AddCheck(name, UPGRADE_CHECK_PASS, fmt.Sprintf("%d nodegroups are %s", len(report.Nodegroups), report.CurrentVersion))
+}
+
+// Nodes not in managed nodegroups like self-managed nodes are checked with kubelet version
+func CheckNodeVersions(report *UpgradeReport, nodes []corev1.Node) {
+	name := UPGRADE_CHECK_NODES
+
+	behind := map[string]int{}
+	for _, node := range nodes {
+		kubeletVersion := node.Status.NodeInfo.KubeletVersion
+		if !isSameMinorVersion(kubeletVersion, report.CurrentVersion) {
+			behind[kubeletVersion]++
+		}
+	}
+
+	if len(behind) > 0 {
+		versions := []string{}
+		for kubeletVersion, count := range behind {
+			versions = append(versions, fmt.Sprintf("%d nodes in %s", count, kubeletVersion))
+		}
+		sort.Strings(versions)
+
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("%s should be %s before upgrade", strings.Join(versions, ","), report.CurrentVersion))
+		return
+	}
+
+	report.AddCheck(name, UPGRADE_CHECK_PASS, fmt.Sprintf("%d nodes are %s", len(nodes), report.CurrentVersion))
+}
+
+// Check whether versions have the same major and minor version
+func isSameMinorVersion(a, b string) bool {
+	aVersion, err := version.ParseGeneric(a)
+	if err != nil {
+		return false
+	}
+
+	bVersion, err := version.ParseGeneric(b)
+	if err != nil {
+		return false
+	}
+
+	return aVersion.Major() == bVersion.Major() && aVersion.Minor() == bVersion.Minor()
+}
+
+// Check objects and helm releases using APIs removed in target version
+func CheckDeprecatedAPIs(ctx context.Context, report *UpgradeReport, clientset kubernetes.Interface, client dynamic.Interface, mapper meta.RESTMapper) {
+	name := UPGRADE_CHECK_DEPRECATED_APIS
+
+	usages, errs := ScanDeprecatedAPIUsages(ctx, clientset, client, mapper, report.TargetVersion)
+	for _, err := range errs {
+		report.addPartialError(name, err)
+	}
+	report.DeprecatedAPIs = usages
+
+	removed := 0
+	for _, usage := range usages {
+		if usage.Removed {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("%d objects use APIs removed in %s", removed, report.TargetVersion))
+	} else if len(errs) > 0 {
+		// Objects which could not be scanned might use removed APIs
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("scan is incomplete with %d errors", len(errs)))
+	} else if len(usages) > 0 {
+		report.AddCheck(name, UPGRADE_CHECK_WARN, fmt.Sprintf("%d objects use deprecated APIs", len(usages)))
+	} else {
+		report.AddCheck(name, UPGRADE_CHECK_PASS, "No object uses deprecated APIs")
+	}
+}
+
+// Check pod disruption budgets which block draining nodes when nodegroups are upgraded
+func CheckPodDisruptionBudgets(ctx context.Context, report *UpgradeReport, client dynamic.Interface, mapper meta.RESTMapper) {
+	name := UPGRADE_CHECK_PDBS
+
+	// policy/v1beta1 is removed in 1.25, so the version served by the cluster is used
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: "policy", Kind: "PodDisruptionBudget"})
+	if err != nil {
+		report.AddError(name, err)
+		return
+	}
+
+	list, err := client.Resource(mapping.Resource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.AddError(name, err)
+		return
+	}
+
+	for _, item := range list.Items {
+		pdb := BlockingPDB{
+			Namespace:          item.GetNamespace(),
+			Name:               item.GetName(),
+			ExpectedPods:       getNestedInt64(item, "status", "expectedPods"),
+			CurrentHealthy:     getNestedInt64(item, "status", "currentHealthy"),
+			DesiredHealthy:     getNestedInt64(item, "status", "desiredHealthy"),
+			DisruptionsAllowed: getNestedInt64(item, "status", "disruptionsAllowed"),
+		}
+
+		if pdb.ExpectedPods > 0 && pdb.DisruptionsAllowed == 0 {
+			report.BlockingPDBs = append(report.BlockingPDBs, pdb)
+		}
+	}
+
+	if len(report.BlockingPDBs) > 0 {
+		report.AddCheck(name, UPGRADE_CHECK_WARN, fmt.Sprintf("%d of %d budgets allow no disruption, which blocks draining nodes", len(report.BlockingPDBs), len(list.Items)))
+		return
+	}
+
+	report.AddCheck(name, UPGRADE_CHECK_PASS, fmt.Sprintf("%d budgets allow disruption", len(list.Items)))
+}
+
+// Get int64 field of unstructured object, which is 0 if not found
+func getNestedInt64(item unstructured.Unstructured, fields ...string) int64 {
+	value, _, _ := unstructured.NestedInt64(item.Object, fields...)
+	return value
+}
+
+// Check available IP addresses in cluster subnets
+func CheckSubnetCapacity(report *UpgradeReport, svc *ec2.EC2, subnetIds []*string) {
+	name := UPGRADE_CHECK_SUBNETS
+
+	if len(subnetIds) == 0 {
+		report.AddCheck(name, UPGRADE_CHECK_WARN, "No subnet is found in cluster configuration")
+		return
+	}
+
+	subnetInfo, err := kubenxaws.GetSubnetsInfo(svc, subnetIds)
+	if err != nil {
+		report.AddError(name, err)
+		return
+	}
+
+	short := []string{}
+	for _, subnet := range subnetInfo.Subnets {
+		subnetName := utils.NO_STRING
+		for _, tag := range subnet.Tags {
+			if aws.StringValue(tag.Key) == "Name" {
+				subnetName = aws.StringValue(tag.Value)
+			}
+		}
+
+		capacity := SubnetCapacity{
+			SubnetId:         aws.StringValue(subnet.SubnetId),
+			Name:             subnetName,
+			AvailabilityZone: aws.StringValue(subnet.AvailabilityZone),
+			AvailableIPs:     aws.Int64Value(subnet.AvailableIpAddressCount),
+		}
+		if capacity.AvailableIPs < EKS_UPGRADE_MIN_SUBNET_IPS {
+			short = append(short, capacity.SubnetId)
+		}
+
+		report.Subnets = append(report.Subnets, capacity)
+	}
+
+	if len(short) == len(report.Subnets) {
+		report.AddCheck(name, UPGRADE_CHECK_FAIL, fmt.Sprintf("No subnet has %d available IP addresses", EKS_UPGRADE_MIN_SUBNET_IPS))
+	} else if len(short) > 0 {
+		report.AddCheck(name, UPGRADE_CHECK_WARN, fmt.Sprintf("%s have less than %d available IP addresses", strings.Join(short, ","), EKS_UPGRADE_MIN_SUBNET_IPS))
+	} else {
+		report.AddCheck(name, UPGRADE_CHECK_PASS, fmt.Sprintf("%d subnets have enough IP addresses", len(report.Subnets)))
+	}
+}
+
+// Render upgrade report with tables of every section
+func RenderUpgradeReport(report *UpgradeReport) {
+	summary := table.GetTableObject()
+	summary.SetHeader([]string{"CHECK", "STATUS", "MESSAGE"})
+	for _, check := range report.Checks {
+		summary.Append([]string{check.Name, check.Status, check.Message})
+	}
+	summary.Render()
+
+	if len(report.Addons) > 0 {
+		fmt.Println()
+		addons := table.GetTableObject()
+		addons.SetHeader([]string{"ADDON", "VERSION", "COMPATIBLE", "TARGET-LATEST", "TARGET-DEFAULT"})
+		for _, addon := range report.Addons {
+			addons.Append([]string{addon.Name, addon.Version, fmt.Sprintf("%t", addon.Compatible), addon.TargetLatest, addon.TargetDefault})
+		}
+		addons.Render()
+	}
+
+	if len(report.Nodegroups) > 0 {
+		fmt.Println()
+		nodegroups := table.GetTableObject()
+		nodegroups.SetHeader([]string{"NODEGROUP", "VERSION", "RELEASE-VERSION", "STATUS", "MESSAGE"})
+		for _, nodegroup := range report.Nodegroups {
+			nodegroups.Append([]string{nodegroup.Name, nodegroup.Version, nodegroup.ReleaseVersion, nodegroup.Status, nodegroup.Message})
+		}
+		nodegroups.Render()
+	}
+
+	if len(report.DeprecatedAPIs) > 0 {
+		fmt.Println()
+		RenderDeprecatedAPIUsageList(report.DeprecatedAPIs)
+	}
+
+	if len(report.BlockingPDBs) > 0 {
+		fmt.Println()
+		pdbs := table.GetTableObject()
+		pdbs.SetHeader([]string{"NAMESPACE", "PDB", "EXPECTED-PODS", "CURRENT-HEALTHY", "DESIRED-HEALTHY", "DISRUPTIONS-ALLOWED"})
+		for _, pdb := range report.BlockingPDBs {
+			pdbs.Append([]string{pdb.Namespace, pdb.Name, fmt.Sprintf("%d", pdb.ExpectedPods), fmt.Sprintf("%d", pdb.CurrentHealthy), fmt.Sprintf("%d", pdb.DesiredHealthy), fmt.Sprintf("%d", pdb.DisruptionsAllowed)})
+		}
+		pdbs.Render()
+	}
+
+	if len(report.Subnets) > 0 {
+		fmt.Println()
+		subnets := table.GetTableObject()
+		subnets.SetHeader([]string{"SUBNET", "NAME", "AVAILABILITY-ZONE", "AVAILABLE-IPS"})
+		for _, subnet := range report.Subnets {
+			subnets.Append([]string{subnet.SubnetId, subnet.Name, subnet.AvailabilityZone, fmt.Sprintf("%d", subnet.AvailableIPs)})
+		}
+		subnets.Render()
+	}
+}
@@ -2,12 +2,18 @@ package table
 
 import (
 	"github.com/olekukonko/tablewriter"
+	"io"
 	"os"
 )
 
 // Get Table
 func GetTableObject() *tablewriter.Table {
-	table := tablewriter.NewWriter(os.Stdout)
+	return GetTableObjectWithWriter(os.Stdout)
+}
+
+// Get Table writing to the writer
+func GetTableObjectWithWriter(out io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(out)
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var (
+	ErrNoClipboard = errors.New("no clipboard command is available")
+)
+
+// Get clipboard commands which could be used on the platform
+func getClipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+
+	commands := [][]string{}
+	if len(os.Getenv("WAYLAND_DISPLAY")) > 0 {
+		commands = append(commands, []string{"wl-copy"})
+	}
+
+	if len(os.Getenv("DISPLAY")) > 0 {
+		commands = append(commands, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+
+	// WSL could use clipboard of windows
+	return append(commands, []string{"clip.exe"})
+}
+
+// Copy text to clipboard with the first available command
+func CopyToClipboard(text string) error {
+	for _, command := range getClipboardCommands() {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+
+	return ErrNoClipboard
+}
@@ -6,22 +6,22 @@ import (
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 	"os"
-	"reflect"
 	"strconv"
-	"unsafe"
 )
 
 var (
 	ALL_NAMESPACE              = ""
 	NO_STRING                  = ""
 	DEFAULT_NODE_LABEL_FILTERS = []string{"app", "env"}
+	DEFAULT_SEARCH_KINDS       = []string{"nodes", "pods", "deployments", "statefulsets", "daemonsets", "cronjobs", "services", "ingresses", "configmaps", "secrets", "serviceaccounts"}
 	//STATIS VALUE
 	KUBENX_HOMEDIR      = ".kubenx"
 	SSH_DEFAULT_PATH    = "ssh"
 	TARGET_DEFAULT_PORT = "22"
 	AWS_IAM_ANNOTATION  = "eks.amazonaws.com/role-arn"
-	AUTH_API_VERSION    = "client.authentication.k8s.io/v1alpha1"
-	AUTH_COMMAND        = "aws"
+	FARGATE_LABEL       = "eks.amazonaws.com/fargate-profile"
+	AUTH_API_VERSION    = "client.authentication.k8s.io/v1beta1"
+	AUTH_COMMAND        = "kubenx"
 
 	//Color Definition
 	Red    = color.New(color.FgRed).PrintlnFunc()
@@ -94,19 +94,3 @@ func GetSingleStringInput(message string) (string, error) {
 
 	return ret, nil
 }
-
-func BytesToString(bytes []byte) (s string) {
-	hdr := *(*reflect.SliceHeader)(unsafe.Pointer(&bytes))
-	return *(*string)(unsafe.Pointer(&reflect.StringHeader{
-		Data: hdr.Data,
-		Len:  hdr.Len,
-	}))
-}
-func StringToBytes(str string) []byte {
-	hdr := *(*reflect.StringHeader)(unsafe.Pointer(&str))
-	return *(*[]byte)(unsafe.Pointer(&reflect.SliceHeader{
-		Data: hdr.Data,
-		Len:  hdr.Len,
-		Cap:  hdr.Len,
-	}))
-}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -26,3 +28,24 @@ func HomeDir() string {
 	}
 	return os.Getenv("USERPROFILE") // windows
 }
+
+// Write file with temporary file and rename, so that readers never see partial content
+func WriteFileAtomically(path string, raw []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// Temporary file is created only readable by owner
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}